@@ -0,0 +1,7 @@
+data "kypo_sandbox_pool" "example" {
+  id = 1
+}
+
+output "example" {
+  value = data.kypo_sandbox_pool.example.definition.url
+}
@@ -0,0 +1,19 @@
+resource "kypo_sandbox_definition" "example" {
+  url = "git@gitlab.ics.muni.cz:muni-kypo-trainings/games/junior-hacker.git"
+  rev = "master"
+}
+
+resource "kypo_sandbox_pool" "example" {
+  definition = {
+    id = kypo_sandbox_definition.example.id
+  }
+  max_size = 2
+}
+
+data "kypo_sandbox_pool_usage" "example" {
+  id = kypo_sandbox_pool.example.id
+}
+
+output "example" {
+  value = data.kypo_sandbox_pool_usage.example.hardware_usage.vcpu
+}
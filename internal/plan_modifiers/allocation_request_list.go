@@ -2,6 +2,7 @@ package plan_modifiers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -11,13 +12,40 @@ import (
 
 var _ planmodifier.List = AllocationRequestStatePlanModifier{}
 
-type AllocationRequestStatePlanModifier struct{}
+// AllocationRequestStatePlanModifier plans all allocation request stages to be `FINISHED`. When one of the stages
+// in the state is `FAILED`, the `on_failed_stage` attribute decides whether the resource is replaced or kept as it is.
+type AllocationRequestStatePlanModifier struct {
+	// StageNames are the names of the stages in the order of the list, used to tell which stage failed.
+	StageNames []string
+}
 
 func (r AllocationRequestStatePlanModifier) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
 	var sandboxUnitAllocationStages []string
+	var onFailedStage types.String
 	req.State.GetAttribute(ctx, path.Root("allocation_request").AtName("stages"), &sandboxUnitAllocationStages)
-	resp.RequiresReplace = slices.Contains(sandboxUnitAllocationStages, "FAILED")
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_failed_stage"), &onFailedStage)...)
+
+	failed := slices.Index(sandboxUnitAllocationStages, "FAILED")
+	if failed < 0 {
+		resp.PlanValue, _ = types.ListValueFrom(ctx, types.StringType, []string{"FINISHED", "FINISHED", "FINISHED"})
+		return
+	}
+
+	stage := fmt.Sprintf("stage %d", failed+1)
+	if failed < len(r.StageNames) {
+		stage = r.StageNames[failed]
+	}
+
+	// The ignored failure is reported once by Read, when it first appears in the state, not on every plan
+	if onFailedStage.ValueString() == "ignore" {
+		resp.PlanValue = req.StateValue
+		return
+	}
+
+	resp.RequiresReplace = true
 	resp.PlanValue, _ = types.ListValueFrom(ctx, types.StringType, []string{"FINISHED", "FINISHED", "FINISHED"})
+	resp.Diagnostics.AddAttributeWarning(req.Path, "Failed Allocation Stage",
+		fmt.Sprintf("The %s stage of the allocation request failed, the allocation unit will be replaced", stage))
 }
 
 func (r AllocationRequestStatePlanModifier) Description(ctx context.Context) string {
@@ -25,6 +53,6 @@ func (r AllocationRequestStatePlanModifier) Description(ctx context.Context) str
 }
 
 func (r AllocationRequestStatePlanModifier) MarkdownDescription(_ context.Context) string {
-	return "Replace is required when one of the stages is `FAILED`, update - which only waits for completion, " +
-		"is required when all stages are not `FINISHED`"
+	return "Replace is required when one of the stages is `FAILED`, unless `on_failed_stage` is `ignore`, " +
+		"update - which only waits for completion, is required when all stages are not `FINISHED`"
 }
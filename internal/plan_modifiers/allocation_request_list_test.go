@@ -0,0 +1,137 @@
+package plan_modifiers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"terraform-provider-kypo/internal/plan_modifiers"
+)
+
+func TestAllocationRequestStatePlanModifier(t *testing.T) {
+	t.Parallel()
+
+	testSchema := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"allocation_request": schema.SingleNestedAttribute{
+				Computed: true,
+				Attributes: map[string]schema.Attribute{
+					"stages": schema.ListAttribute{
+						Computed:    true,
+						ElementType: types.StringType,
+					},
+				},
+			},
+			"on_failed_stage": schema.StringAttribute{
+				Optional: true,
+			},
+		},
+	}
+	stagesPath := path.Root("allocation_request").AtName("stages")
+	stagesType := tftypes.List{ElementType: tftypes.String}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"allocation_request": tftypes.Object{AttributeTypes: map[string]tftypes.Type{"stages": stagesType}},
+		"on_failed_stage":    tftypes.String,
+	}}
+
+	newValue := func(stages []string, onFailedStage *string) tftypes.Value {
+		stageValues := make([]tftypes.Value, 0, len(stages))
+		for _, stage := range stages {
+			stageValues = append(stageValues, tftypes.NewValue(tftypes.String, stage))
+		}
+		return tftypes.NewValue(objectType, map[string]tftypes.Value{
+			"allocation_request": tftypes.NewValue(objectType.AttributeTypes["allocation_request"], map[string]tftypes.Value{
+				"stages": tftypes.NewValue(stagesType, stageValues),
+			}),
+			"on_failed_stage": tftypes.NewValue(tftypes.String, onFailedStage),
+		})
+	}
+	listValue := func(stages ...string) types.List {
+		value, _ := types.ListValueFrom(context.Background(), types.StringType, stages)
+		return value
+	}
+	ignore := "ignore"
+	replace := "replace"
+
+	type testCase struct {
+		stages                  []string
+		onFailedStage           *string
+		expectedPlanValue       types.List
+		expectedRequiresReplace bool
+		expectedDiagnostics     diag.Diagnostics
+	}
+
+	tests := map[string]testCase{
+		"finished": {
+			stages:            []string{"FINISHED", "FINISHED", "FINISHED"},
+			expectedPlanValue: listValue("FINISHED", "FINISHED", "FINISHED"),
+		},
+		"running": {
+			stages:            []string{"FINISHED", "RUNNING", "IN_QUEUE"},
+			expectedPlanValue: listValue("FINISHED", "FINISHED", "FINISHED"),
+		},
+		"failed-default": {
+			stages:                  []string{"FINISHED", "FAILED", "IN_QUEUE"},
+			expectedPlanValue:       listValue("FINISHED", "FINISHED", "FINISHED"),
+			expectedRequiresReplace: true,
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(stagesPath, "Failed Allocation Stage",
+					"The networking_ansible stage of the allocation request failed, the allocation unit will be replaced"),
+			},
+		},
+		"failed-replace": {
+			stages:                  []string{"FAILED", "IN_QUEUE", "IN_QUEUE"},
+			onFailedStage:           &replace,
+			expectedPlanValue:       listValue("FINISHED", "FINISHED", "FINISHED"),
+			expectedRequiresReplace: true,
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(stagesPath, "Failed Allocation Stage",
+					"The terraform stage of the allocation request failed, the allocation unit will be replaced"),
+			},
+		},
+		"failed-ignore": {
+			stages:            []string{"FINISHED", "FINISHED", "FAILED"},
+			onFailedStage:     &ignore,
+			expectedPlanValue: listValue("FINISHED", "FINISHED", "FAILED"),
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			value := newValue(test.stages, test.onFailedStage)
+			request := planmodifier.ListRequest{
+				Path:       stagesPath,
+				State:      tfsdk.State{Schema: testSchema, Raw: value},
+				Plan:       tfsdk.Plan{Schema: testSchema, Raw: value},
+				StateValue: listValue(test.stages...),
+				PlanValue:  types.ListUnknown(types.StringType),
+			}
+			response := planmodifier.ListResponse{PlanValue: request.PlanValue}
+
+			plan_modifiers.AllocationRequestStatePlanModifier{
+				StageNames: []string{"terraform", "networking_ansible", "user_ansible"},
+			}.PlanModifyList(ctx, request, &response)
+
+			if diff := cmp.Diff(response.PlanValue, test.expectedPlanValue); diff != "" {
+				t.Errorf("unexpected plan value difference: %s", diff)
+			}
+			if response.RequiresReplace != test.expectedRequiresReplace {
+				t.Errorf("expected RequiresReplace %t, got %t", test.expectedRequiresReplace, response.RequiresReplace)
+			}
+			if diff := cmp.Diff(response.Diagnostics, test.expectedDiagnostics); diff != "" {
+				t.Errorf("unexpected diagnostics difference: %s", diff)
+			}
+		})
+	}
+}
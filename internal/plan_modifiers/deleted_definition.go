@@ -0,0 +1,43 @@
+package plan_modifiers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+)
+
+var _ planmodifier.Int64 = DeletedDefinitionPlanModifier{}
+
+// DeletedDefinitionPrivateKey is the private state key under which Read records that the sandbox definition
+// referenced by the resource no longer exists.
+const DeletedDefinitionPrivateKey = "deleted_definition"
+
+// DeletedDefinitionPlanModifier requires replace when the configured sandbox definition id is the one in the state,
+// but Read found that the sandbox definition no longer exists, e.g. because it was replaced outside of Terraform.
+type DeletedDefinitionPlanModifier struct{}
+
+func (r DeletedDefinitionPlanModifier) PlanModifyInt64(ctx context.Context, req planmodifier.Int64Request, resp *planmodifier.Int64Response) {
+	// Nothing to replace on create or destroy, a changed id is replaced by RequiresReplace
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() || !req.PlanValue.Equal(req.StateValue) {
+		return
+	}
+
+	deleted, diags := req.Private.GetKey(ctx, DeletedDefinitionPrivateKey)
+	resp.Diagnostics.Append(diags...)
+	if deleted == nil {
+		return
+	}
+
+	resp.RequiresReplace = true
+	resp.Diagnostics.AddAttributeWarning(req.Path, "Sandbox Definition Deleted",
+		fmt.Sprintf("Sandbox definition %d no longer exists, the sandbox pool will be replaced", req.StateValue.ValueInt64()))
+}
+
+func (r DeletedDefinitionPlanModifier) Description(ctx context.Context) string {
+	return r.MarkdownDescription(ctx)
+}
+
+func (r DeletedDefinitionPlanModifier) MarkdownDescription(_ context.Context) string {
+	return "Replace is required when the sandbox definition with the configured id no longer exists"
+}
@@ -0,0 +1,118 @@
+package plan_modifiers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"terraform-provider-kypo/internal/plan_modifiers"
+)
+
+// newPrivate returns empty private state data of the type of the given pointer. The type is internal to the framework.
+func newPrivate[T any](_ *T) *T {
+	return new(T)
+}
+
+func TestDeletedDefinitionPlanModifier(t *testing.T) {
+	t.Parallel()
+
+	testSchema := schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"definition_id": schema.Int64Attribute{
+				Required: true,
+			},
+		},
+	}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"definition_id": tftypes.Number}}
+	newValue := func(definitionId *int64) tftypes.Value {
+		if definitionId == nil {
+			return tftypes.NewValue(objectType, nil)
+		}
+		return tftypes.NewValue(objectType, map[string]tftypes.Value{
+			"definition_id": tftypes.NewValue(tftypes.Number, *definitionId),
+		})
+	}
+	int64Value := func(value *int64) types.Int64 {
+		if value == nil {
+			return types.Int64Null()
+		}
+		return types.Int64Value(*value)
+	}
+	three, four := int64(3), int64(4)
+
+	type testCase struct {
+		stateId                 *int64
+		planId                  *int64
+		deleted                 bool
+		expectedRequiresReplace bool
+		expectedDiagnostics     diag.Diagnostics
+	}
+
+	tests := map[string]testCase{
+		"exists": {
+			stateId: &three,
+			planId:  &three,
+		},
+		"deleted": {
+			stateId:                 &three,
+			planId:                  &three,
+			deleted:                 true,
+			expectedRequiresReplace: true,
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(path.Root("definition_id"), "Sandbox Definition Deleted",
+					"Sandbox definition 3 no longer exists, the sandbox pool will be replaced"),
+			},
+		},
+		// The changed id is replaced by RequiresReplace
+		"deleted-changed-id": {
+			stateId: &three,
+			planId:  &four,
+			deleted: true,
+		},
+		"create": {
+			planId: &three,
+		},
+		"destroy": {
+			stateId: &three,
+			deleted: true,
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			request := planmodifier.Int64Request{
+				Path:       path.Root("definition_id"),
+				State:      tfsdk.State{Schema: testSchema, Raw: newValue(test.stateId)},
+				StateValue: int64Value(test.stateId),
+				Plan:       tfsdk.Plan{Schema: testSchema, Raw: newValue(test.planId)},
+				PlanValue:  int64Value(test.planId),
+			}
+			request.Private = newPrivate(request.Private)
+			if test.deleted {
+				request.Private.SetKey(ctx, plan_modifiers.DeletedDefinitionPrivateKey, []byte("true"))
+			}
+			response := planmodifier.Int64Response{PlanValue: request.PlanValue}
+
+			plan_modifiers.DeletedDefinitionPlanModifier{}.PlanModifyInt64(ctx, request, &response)
+
+			if response.RequiresReplace != test.expectedRequiresReplace {
+				t.Errorf("expected requires replace %t, got %t", test.expectedRequiresReplace, response.RequiresReplace)
+			}
+			if diff := cmp.Diff(response.Diagnostics, test.expectedDiagnostics); diff != "" {
+				t.Errorf("unexpected diagnostics difference: %s", diff)
+			}
+		})
+	}
+}
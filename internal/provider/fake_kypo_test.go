@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+// fakeKypo serves the parts of the KYPO sandbox service API used by the resources, so that their unexported
+// helpers can be tested without a KYPO instance. Allocation units are deleted as soon as their cleanup request
+// is created, which frees their slot in the pool, and cancelled allocation requests fail right away.
+type fakeKypo struct {
+	mu          sync.Mutex
+	pools       map[int64]kypo.SandboxPool
+	definitions map[int64]kypo.SandboxDefinition
+	units       map[int64]kypo.SandboxAllocationUnit
+	// allocationStages are the stages of the allocation requests of created allocation units
+	allocationStages []string
+	// failedAllocations is the number of the next created allocation units whose allocation request fails
+	failedAllocations int
+	// outputs holds the output lines of allocation request stages, keyed by the request id and the stage
+	outputs  map[string][]string
+	lastId   int64
+	requests []string
+}
+
+func newFakeKypo(t *testing.T) (*fakeKypo, *kypo.Client) {
+	t.Helper()
+
+	f := &fakeKypo{
+		pools:       map[int64]kypo.SandboxPool{},
+		definitions: map[int64]kypo.SandboxDefinition{},
+		units:       map[int64]kypo.SandboxAllocationUnit{},
+		// The allocation of created allocation units never finishes by default
+		allocationStages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"},
+		outputs:          map[string][]string{},
+		lastId:           100,
+	}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	client, err := kypo.NewClientWithToken(server.URL, "", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+// requested returns the requests received so far, each as the method and the path without the API prefix.
+func (f *fakeKypo) requested() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeKypo) unit(id int64) (kypo.SandboxAllocationUnit, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	unit, ok := f.units[id]
+	return unit, ok
+}
+
+func (f *fakeKypo) serve(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	urlPath := strings.TrimPrefix(req.URL.Path, "/kypo-sandbox-service/api/v1")
+	f.requests = append(f.requests, req.Method+" "+urlPath)
+
+	// Numeric segments are replaced by {id}, so that routes can be matched as strings
+	var ids []int64
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	for i, segment := range segments {
+		if id, err := strconv.ParseInt(segment, 10, 64); err == nil {
+			ids = append(ids, id)
+			segments[i] = "{id}"
+		}
+	}
+
+	switch route := req.Method + " " + strings.Join(segments, "/"); route {
+	case "POST pools":
+		f.createPool(w, req)
+	case "GET pools/{id}":
+		pool, ok := f.pools[ids[0]]
+		respond(w, ok, http.StatusOK, pool)
+	case "POST pools/{id}/sandbox-allocation-units":
+		f.createAllocationUnits(w, req, ids[0])
+	case "GET definitions/{id}":
+		definition, ok := f.definitions[ids[0]]
+		respond(w, ok, http.StatusOK, definition)
+	case "GET sandbox-allocation-units/{id}":
+		unit, ok := f.units[ids[0]]
+		respond(w, ok, http.StatusOK, unit)
+	case "POST sandbox-allocation-units/{id}/cleanup-request":
+		unit, ok := f.units[ids[0]]
+		if pool, exists := f.pools[unit.PoolId]; ok && exists {
+			pool.Size--
+			f.pools[unit.PoolId] = pool
+		}
+		delete(f.units, ids[0])
+		respond(w, ok, http.StatusCreated, struct{}{})
+	case "PATCH allocation-requests/{id}/cancel":
+		for unitId, unit := range f.units {
+			if unit.AllocationRequest.Id == ids[0] {
+				unit.AllocationRequest.Stages = []string{"FAILED", "FAILED", "FAILED"}
+				f.units[unitId] = unit
+				respond(w, true, http.StatusOK, struct{}{})
+				return
+			}
+		}
+		respond(w, false, http.StatusOK, nil)
+	case "GET allocation-requests/{id}/stages/terraform/outputs",
+		"GET allocation-requests/{id}/stages/networking-ansible/outputs",
+		"GET allocation-requests/{id}/stages/user-ansible/outputs":
+		f.serveOutputs(w, req, ids[0], segments[3])
+	default:
+		respond(w, false, http.StatusOK, nil)
+	}
+}
+
+// serveOutputs serves a page of stage output lines in the same shape as KYPO.
+func (f *fakeKypo) serveOutputs(w http.ResponseWriter, req *http.Request, requestId int64, stage string) {
+	lines := f.outputs[strconv.FormatInt(requestId, 10)+"/"+stage]
+	page, _ := strconv.ParseInt(req.URL.Query().Get("page"), 10, 64)
+	pageSize, _ := strconv.ParseInt(req.URL.Query().Get("page_size"), 10, 64)
+
+	type outputLine struct {
+		Content string `json:"content"`
+	}
+	output := kypo.Pagination[[]outputLine]{
+		Page:       page,
+		PageSize:   pageSize,
+		PageCount:  max((int64(len(lines))+pageSize-1)/pageSize, 1),
+		TotalCount: int64(len(lines)),
+		Results:    []outputLine{},
+	}
+	for i := (page - 1) * pageSize; i < page*pageSize && i < int64(len(lines)); i++ {
+		output.Results = append(output.Results, outputLine{Content: lines[i]})
+	}
+	output.Count = int64(len(output.Results))
+
+	respond(w, true, http.StatusOK, output)
+}
+
+// createPool creates an empty pool of the requested sandbox definition.
+func (f *fakeKypo) createPool(w http.ResponseWriter, req *http.Request) {
+	var request struct {
+		DefinitionId int64 `json:"definition_id"`
+		MaxSize      int64 `json:"max_size"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.lastId++
+	pool := kypo.SandboxPool{Id: f.lastId, MaxSize: request.MaxSize, Definition: f.definitions[request.DefinitionId]}
+	f.pools[pool.Id] = pool
+	respond(w, true, http.StatusCreated, pool)
+}
+
+// createAllocationUnits creates allocation units in the pool, failing like KYPO when the pool has no free slot.
+func (f *fakeKypo) createAllocationUnits(w http.ResponseWriter, req *http.Request, poolId int64) {
+	pool, ok := f.pools[poolId]
+	if !ok {
+		respond(w, false, http.StatusOK, nil)
+		return
+	}
+	count, _ := strconv.ParseInt(req.URL.Query().Get("count"), 10, 64)
+	if pool.Size+count > pool.MaxSize {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	pool.Size += count
+	f.pools[poolId] = pool
+
+	created := kypo.Pagination[[]kypo.SandboxAllocationUnit]{Results: []kypo.SandboxAllocationUnit{}}
+	for i := int64(0); i < count; i++ {
+		f.lastId++
+		stages := append([]string(nil), f.allocationStages...)
+		if f.failedAllocations > 0 {
+			f.failedAllocations--
+			stages = []string{"FINISHED", "FAILED", "IN_QUEUE"}
+		}
+		unit := kypo.SandboxAllocationUnit{
+			Id:     f.lastId,
+			PoolId: poolId,
+			AllocationRequest: kypo.SandboxRequest{
+				Id: f.lastId, AllocationUnitId: f.lastId, Stages: stages,
+			},
+		}
+		f.units[unit.Id] = unit
+		created.Results = append(created.Results, unit)
+	}
+	respond(w, true, http.StatusOK, created)
+}
+
+func respond(w http.ResponseWriter, found bool, statusCode int, body any) {
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// testPollTimes sets short poll times in test states, so that tests do not wait for the default ones.
+var testPollTimes = struct {
+	Create string `tfsdk:"create"`
+	Delete string `tfsdk:"delete"`
+}{Create: "1ms", Delete: "1ms"}
+
+// newTestState returns a state of the given resource with the given attributes set and all other attributes null.
+func newTestState(t *testing.T, r resource.Resource, attributes map[string]any) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	schemaResp := resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	for name, value := range attributes {
+		diags := state.SetAttribute(ctx, path.Root(name), value)
+		if diags.HasError() {
+			t.Fatalf("unable to set attribute %s: %v", name, diags)
+		}
+	}
+	return state
+}
+
+// newTestPrivate returns empty private state data of the type of the given pointer. The type is internal to the framework.
+func newTestPrivate[T any](_ *T) *T {
+	return new(T)
+}
@@ -218,6 +218,7 @@ func (p *KypoProvider) Resources(_ context.Context) []func() resource.Resource {
 		NewSandboxDefinitionResource,
 		NewSandboxPoolResource,
 		NewSandboxAllocationUnitResource,
+		NewSandboxAllocationUnitsResource,
 		NewTrainingDefinitionResource,
 		NewTrainingDefinitionAdaptiveResource,
 	}
@@ -226,6 +227,8 @@ func (p *KypoProvider) Resources(_ context.Context) []func() resource.Resource {
 func (p *KypoProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewSandboxRequestOutputDataSource,
+		NewSandboxPoolDataSource,
+		NewSandboxPoolUsageDataSource,
 	}
 }
 
@@ -4,16 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -30,6 +38,8 @@ import (
 var _ resource.Resource = &sandboxAllocationUnitResource{}
 var _ resource.ResourceWithImportState = &sandboxAllocationUnitResource{}
 var _ resource.ResourceWithConfigure = &sandboxAllocationUnitResource{}
+var _ resource.ResourceWithModifyPlan = &sandboxAllocationUnitResource{}
+var _ resource.ResourceWithValidateConfig = &sandboxAllocationUnitResource{}
 
 func NewSandboxAllocationUnitResource() resource.Resource {
 	return &sandboxAllocationUnitResource{}
@@ -45,6 +55,90 @@ type response struct {
 	Diagnostics *diag.Diagnostics
 }
 
+// allocationStageNames are the names of the allocation request stages in the order of kypo.SandboxRequest Stages.
+var allocationStageNames = []string{"terraform", "networking_ansible", "user_ansible"}
+
+// cleanupStageNames are the names of the cleanup request stages in the order of kypo.SandboxRequest Stages.
+// KYPO reports only the states of the stages, not their names. The sandbox service creates the cleanup stages
+// in the reverse order of the allocation stages, the user Ansible cleanup first and the Terraform stack deletion
+// last, and lists them in the order they were created.
+var cleanupStageNames = []string{"user_ansible", "networking_ansible", "terraform"}
+
+// sandboxAllocationUnitModel is kypo.SandboxAllocationUnit with the request stages also available by name.
+type sandboxAllocationUnitModel struct {
+	Id                int64               `tfsdk:"id"`
+	PoolId            int64               `tfsdk:"pool_id"`
+	AllocationRequest sandboxRequestModel `tfsdk:"allocation_request"`
+	CleanupRequest    sandboxRequestModel `tfsdk:"cleanup_request"`
+	CreatedBy         kypo.User           `tfsdk:"created_by"`
+	Locked            bool                `tfsdk:"locked"`
+}
+
+type sandboxRequestModel struct {
+	Id               int64                       `tfsdk:"id"`
+	AllocationUnitId int64                       `tfsdk:"allocation_unit_id"`
+	Created          string                      `tfsdk:"created"`
+	Stages           []string                    `tfsdk:"stages"`
+	StageDetails     map[string]stageDetailModel `tfsdk:"stage_details"`
+}
+
+type stageDetailModel struct {
+	State string `tfsdk:"state"`
+}
+
+func newSandboxRequestModel(request kypo.SandboxRequest, stageNames []string) sandboxRequestModel {
+	stageDetails := make(map[string]stageDetailModel, len(request.Stages))
+	for i, state := range request.Stages {
+		if i < len(stageNames) {
+			stageDetails[stageNames[i]] = stageDetailModel{State: state}
+		}
+	}
+	return sandboxRequestModel{
+		Id:               request.Id,
+		AllocationUnitId: request.AllocationUnitId,
+		Created:          request.Created,
+		Stages:           request.Stages,
+		StageDetails:     stageDetails,
+	}
+}
+
+func newSandboxAllocationUnitModel(allocationUnit kypo.SandboxAllocationUnit) sandboxAllocationUnitModel {
+	return sandboxAllocationUnitModel{
+		Id:                allocationUnit.Id,
+		PoolId:            allocationUnit.PoolId,
+		AllocationRequest: newSandboxRequestModel(allocationUnit.AllocationRequest, allocationStageNames),
+		CleanupRequest:    newSandboxRequestModel(allocationUnit.CleanupRequest, cleanupStageNames),
+		CreatedBy:         allocationUnit.CreatedBy,
+		Locked:            allocationUnit.Locked,
+	}
+}
+
+// allocationFailureModel records an allocation attempt which failed and was retried.
+type allocationFailureModel struct {
+	AllocationUnitId    int64  `tfsdk:"allocation_unit_id"`
+	AllocationRequestId int64  `tfsdk:"allocation_request_id"`
+	FailedStage         string `tfsdk:"failed_stage"`
+}
+
+// failedStage returns the name of the first `FAILED` stage of the request, or an empty string when no stage failed.
+func failedStage(request kypo.SandboxRequest, stageNames []string) string {
+	for i, state := range request.Stages {
+		if state == "FAILED" && i < len(stageNames) {
+			return stageNames[i]
+		}
+	}
+	return ""
+}
+
+// stageState returns the state of the named stage of the request, or an empty string when the stage is missing.
+func stageState(request kypo.SandboxRequest, stageNames []string, stageName string) string {
+	index := slices.Index(stageNames, stageName)
+	if index < 0 || index >= len(request.Stages) {
+		return ""
+	}
+	return request.Stages[index]
+}
+
 func setState(ctx context.Context, stateValue any, resp response) {
 	valueOf := reflect.ValueOf(stateValue)
 	typeOf := reflect.TypeOf(stateValue)
@@ -55,17 +149,17 @@ func setState(ctx context.Context, stateValue any, resp response) {
 }
 
 func checkAllocationRequestResult(allocationUnit *kypo.SandboxAllocationUnit, diagnostics *diag.Diagnostics, warningOnAllocationFailureBool bool, id int64) {
-	if allocationUnit.AllocationRequest.Stages[0] != "FINISHED" {
+	if stageState(allocationUnit.AllocationRequest, allocationStageNames, "terraform") != "FINISHED" {
 		warningOrError(diagnostics, warningOnAllocationFailureBool, "Sandbox Creation Error - Terraform Stage Failed",
 			fmt.Sprintf("Creation of sandbox allocation unit %d finished with error in Terraform stage", id))
 		return
 	}
-	if allocationUnit.AllocationRequest.Stages[1] != "FINISHED" {
+	if stageState(allocationUnit.AllocationRequest, allocationStageNames, "networking_ansible") != "FINISHED" {
 		warningOrError(diagnostics, warningOnAllocationFailureBool, "Sandbox Creation Error - Ansible Stage Failed",
 			fmt.Sprintf("Creation of sandbox allocation unit %d finished with error in Networking Ansible stage", id))
 		return
 	}
-	if allocationUnit.AllocationRequest.Stages[2] != "FINISHED" {
+	if stageState(allocationUnit.AllocationRequest, allocationStageNames, "user_ansible") != "FINISHED" {
 		warningOrError(diagnostics, warningOnAllocationFailureBool, "Sandbox Creation Error - User Stage Failed",
 			fmt.Sprintf("Creation of sandbox allocation unit %d finished with error in User Ansible stage", id))
 		return
@@ -118,6 +212,86 @@ func getPollTime(diags *diag.Diagnostics, ctx context.Context, pollTimeObject ty
 	return pollTime
 }
 
+// pollStrategy computes the time to wait before each check of a sandbox request.
+type pollStrategy struct {
+	strategy   string
+	min        time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// getPollStrategy reads the `polling` configuration. The poll time of the operation is used
+// by the `fixed` strategy and as the default minimum of the other strategies.
+func getPollStrategy(diags *diag.Diagnostics, ctx context.Context, pollingObject types.Object, pollTime time.Duration) pollStrategy {
+	poll := pollStrategy{strategy: "fixed", min: pollTime, max: 2 * time.Minute, multiplier: 2}
+	if pollingObject.IsNull() || pollingObject.IsUnknown() {
+		return poll
+	}
+
+	attributes := pollingObject.Attributes()
+	if value, ok := attributes["strategy"].(types.String); ok && !value.IsNull() && !value.IsUnknown() {
+		poll.strategy = value.ValueString()
+	}
+	// The fixed strategy always checks once every poll time, `min` only applies to the other strategies
+	if poll.strategy != "fixed" {
+		poll.min = getPollTime(diags, ctx, pollingObject, "min", poll.min)
+	}
+	poll.max = getPollTime(diags, ctx, pollingObject, "max", poll.max)
+	if value, ok := attributes["multiplier"].(types.Float64); ok && !value.IsNull() && !value.IsUnknown() {
+		poll.multiplier = value.ValueFloat64()
+	}
+	if value, ok := attributes["jitter"].(types.Float64); ok && !value.IsNull() && !value.IsUnknown() {
+		poll.jitter = value.ValueFloat64()
+	}
+
+	if poll.max < poll.min {
+		poll.max = poll.min
+	}
+	return poll
+}
+
+// next returns the time to wait before the next check, given how many checks were already done
+// and the request seen by the last check, whose stages are named by stageNames.
+func (p pollStrategy) next(checks int, request kypo.SandboxRequest, stageNames []string) time.Duration {
+	interval := p.min
+	switch p.strategy {
+	case "exponential":
+		scaled := float64(p.min) * math.Pow(p.multiplier, float64(checks))
+		if scaled < float64(p.max) {
+			interval = time.Duration(scaled)
+		} else {
+			interval = p.max
+		}
+	case "stage_aware":
+		// The allocation starts with the Terraform stage, which creates the stack and takes minutes. The Ansible stages
+		// and cleanups, which delete the stack last, are checked more often to notice the completion early. Before
+		// the request is first seen, it has no stages and the first check is done after `min` as well.
+		if len(stageNames) > 0 && stageNames[0] == "terraform" {
+			if state := stageState(request, stageNames, "terraform"); state == "IN_QUEUE" || state == "RUNNING" {
+				interval = p.max
+			}
+		}
+	}
+
+	if p.jitter > 0 {
+		interval = time.Duration(float64(interval) * (1 + p.jitter*(2*rand.Float64()-1)))
+	}
+	return interval
+}
+
+// wait blocks until the duration elapses or the context is done.
+func wait(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func (r *sandboxAllocationUnitResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_sandbox_allocation_unit"
 }
@@ -161,9 +335,21 @@ func (r *sandboxAllocationUnitResource) Schema(ctx context.Context, _ resource.S
 					"stages": schema.ListAttribute{
 						Computed:            true,
 						ElementType:         types.StringType,
-						MarkdownDescription: "Statuses of the allocation stages. List of three strings, where each is one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`",
+						MarkdownDescription: "Statuses of the allocation stages. List of three strings in the order of the `terraform`, `networking_ansible` and `user_ansible` stages, where each is one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`. The same statuses are available by name in `stage_details`",
 						PlanModifiers: []planmodifier.List{
-							plan_modifiers.AllocationRequestStatePlanModifier{},
+							plan_modifiers.AllocationRequestStatePlanModifier{StageNames: allocationStageNames},
+						},
+					},
+					"stage_details": schema.MapNestedAttribute{
+						Computed:            true,
+						MarkdownDescription: "Details of the allocation stages keyed by the stage name, which is one of `terraform`, `networking_ansible` or `user_ansible`",
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"state": schema.StringAttribute{
+									Computed:            true,
+									MarkdownDescription: "Status of the stage, one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`",
+								},
+							},
 						},
 					},
 				},
@@ -187,7 +373,19 @@ func (r *sandboxAllocationUnitResource) Schema(ctx context.Context, _ resource.S
 					"stages": schema.ListAttribute{
 						Computed:            true,
 						ElementType:         types.StringType,
-						MarkdownDescription: "Statuses of cleanup stages. List of three strings, where each is one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`",
+						MarkdownDescription: "Statuses of cleanup stages. List of three strings in the order of the `user_ansible`, `networking_ansible` and `terraform` stages, where each is one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`. The same statuses are available by name in `stage_details`",
+					},
+					"stage_details": schema.MapNestedAttribute{
+						Computed:            true,
+						MarkdownDescription: "Details of the cleanup stages keyed by the stage name, which is one of `terraform`, `networking_ansible` or `user_ansible`",
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"state": schema.StringAttribute{
+									Computed:            true,
+									MarkdownDescription: "Status of the stage, one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`",
+								},
+							},
+						},
 					},
 				},
 			},
@@ -229,6 +427,92 @@ func (r *sandboxAllocationUnitResource) Schema(ctx context.Context, _ resource.S
 				MarkdownDescription: "Whether to emit a warning instead of error when one of the allocation request stages fails",
 				Optional:            true,
 			},
+			"prevent_destroy_when_locked": schema.BoolAttribute{
+				MarkdownDescription: "Whether destroying the allocation unit fails while it is locked, defaults to `true`. " +
+					"A locked allocation unit is used by a training run and destroying it destroys the sandbox of the trainee",
+				Optional: true,
+			},
+			"force_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Whether to destroy the allocation unit even when it is locked and `prevent_destroy_when_locked` is set. " +
+					"Must be applied before the destroy to take effect",
+				Optional: true,
+			},
+			"wait_for_capacity": schema.BoolAttribute{
+				MarkdownDescription: "Whether to wait until the pool has a free slot instead of failing when the pool is full. The wait is bounded by the `create` timeout",
+				Optional:            true,
+			},
+			"capacity_poll": schema.StringAttribute{
+				MarkdownDescription: "Time after which the pool is checked for a free slot when `wait_for_capacity` is set, defaults to `30s`. " +
+					"The time is a string which can be [parsed as a duration](https://pkg.go.dev/time#ParseDuration).",
+				Optional: true,
+				Validators: []validator.String{
+					validators.TimeDuration(),
+				},
+			},
+			"on_timeout": schema.StringAttribute{
+				MarkdownDescription: "What to do when the `create` timeout expires before the allocation finishes, defaults to `keep`. " +
+					"One of `keep`, which keeps the allocation running and the tainted allocation unit in the state, " +
+					"or `cancel_and_cleanup`, which cancels the allocation request and cleans up the allocation unit within the `delete` timeout",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("keep", "cancel_and_cleanup"),
+				},
+			},
+			"on_failed_stage": schema.StringAttribute{
+				MarkdownDescription: "What to plan when a stage of the allocation request in the state is `FAILED`, defaults to `replace`. " +
+					"One of `replace`, which replaces the allocation unit, or `ignore`, which keeps the failed allocation unit, for example for debugging. " +
+					"The ignored failure is reported as a warning once, when it first appears in the state. " +
+					"`rerun_stage`, which would re-run only the failed stage, is not supported, because KYPO provides no way to re-run a stage of an allocation request",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("replace", "ignore"),
+				},
+			},
+			"allocation_retries": schema.Int64Attribute{
+				MarkdownDescription: "How many times to retry a failed allocation, defaults to `0`. On each retry, the failed allocation unit is cleaned up and a new one is allocated in the same pool. Only used by the `Create` operation.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_on_stages": schema.ListAttribute{
+				MarkdownDescription: "Stages whose failure is retried, each one of `terraform`, `networking_ansible` or `user_ansible`. Defaults to all stages.",
+				Optional:            true,
+				ElementType:         types.StringType,
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.OneOf(allocationStageNames...)),
+				},
+			},
+			"allocation_attempts": schema.Int64Attribute{
+				MarkdownDescription: "How many allocation units were allocated to get the current one",
+				Computed:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"allocation_failures": schema.ListNestedAttribute{
+				MarkdownDescription: "Failed allocation attempts which were cleaned up and retried",
+				Computed:            true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"allocation_unit_id": schema.Int64Attribute{
+							MarkdownDescription: "Id of the failed allocation unit",
+							Computed:            true,
+						},
+						"allocation_request_id": schema.Int64Attribute{
+							MarkdownDescription: "Id of the failed allocation request",
+							Computed:            true,
+						},
+						"failed_stage": schema.StringAttribute{
+							MarkdownDescription: "Name of the stage which failed, one of `terraform`, `networking_ansible` or `user_ansible`",
+							Computed:            true,
+						},
+					},
+				},
+			},
 			"timeouts": timeouts.AttributesAll(ctx),
 			"poll_times": schema.SingleNestedAttribute{
 				MarkdownDescription: "Times after which the result of the operation is periodically checked. Times are strings which can be [parsed as a duration](https://pkg.go.dev/time#ParseDuration).",
@@ -250,10 +534,81 @@ func (r *sandboxAllocationUnitResource) Schema(ctx context.Context, _ resource.S
 					},
 				},
 			},
+			"polling": schema.SingleNestedAttribute{
+				MarkdownDescription: "How often the allocation and cleanup requests are checked. Times are strings which can be [parsed as a duration](https://pkg.go.dev/time#ParseDuration).",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"strategy": schema.StringAttribute{
+						MarkdownDescription: "One of `fixed`, which checks once every poll time from `poll_times`, " +
+							"`exponential`, which starts at `min` and multiplies the time by `multiplier` after each check up to `max`, " +
+							"or `stage_aware`, which checks once every `max` while the Terraform stage of an allocation is queued or running and once every `min` otherwise, " +
+							"including the first check and the whole cleanup. Defaults to `fixed`.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf("fixed", "exponential", "stage_aware"),
+						},
+					},
+					"min": schema.StringAttribute{
+						MarkdownDescription: "Shortest time between checks of the `exponential` and `stage_aware` strategies, defaults to the poll time from `poll_times`.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+					"max": schema.StringAttribute{
+						MarkdownDescription: "Longest time between checks of the `exponential` and `stage_aware` strategies, defaults to `2m`.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+					"multiplier": schema.Float64Attribute{
+						MarkdownDescription: "Factor by which the `exponential` strategy lengthens the time after each check, defaults to `2`.",
+						Optional:            true,
+						Validators: []validator.Float64{
+							float64validator.AtLeast(1),
+						},
+					},
+					"jitter": schema.Float64Attribute{
+						MarkdownDescription: "Fraction by which each time between checks is randomly lengthened or shortened, so that many allocation units are not checked at once. At most `0.5`, defaults to `0`.",
+						Optional:            true,
+						Validators: []validator.Float64{
+							float64validator.Between(0, 0.5),
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+func (r *sandboxAllocationUnitResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var minPollTime, maxPollTime types.String
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("polling").AtName("min"), &minPollTime)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("polling").AtName("max"), &maxPollTime)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if minPollTime.IsNull() || minPollTime.IsUnknown() || maxPollTime.IsNull() || maxPollTime.IsUnknown() {
+		return
+	}
+
+	minDuration, minErr := time.ParseDuration(minPollTime.ValueString())
+	maxDuration, maxErr := time.ParseDuration(maxPollTime.ValueString())
+	// Values which cannot be parsed are reported by the TimeDuration validator
+	if minErr != nil || maxErr != nil {
+		return
+	}
+
+	if minDuration > maxDuration {
+		resp.Diagnostics.AddAttributeError(path.Root("polling").AtName("max"), "Invalid Attribute Value",
+			fmt.Sprintf("polling max %s must not be shorter than polling min %s", maxDuration, minDuration))
+	}
+}
+
 func (r *sandboxAllocationUnitResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
@@ -273,16 +628,291 @@ func (r *sandboxAllocationUnitResource) Configure(_ context.Context, req resourc
 	r.client = client
 }
 
+// stageOutputPageSize is the number of output lines requested at once when logging the stage outputs.
+// Output of running stages is logged by whole pages, so the page is kept small.
+const stageOutputPageSize = 20
+
+// stageOutputTypes are the output types of GetSandboxRequestAnsibleOutputs in the order of allocationStageNames.
+var stageOutputTypes = []string{"terraform", "networking-ansible", "user-ansible"}
+
+// allocationProgress logs the progress of an allocation request. It remembers the last seen
+// stage states and how many output lines of each stage were already logged.
+type allocationProgress struct {
+	client           *kypo.Client
+	allocationUnitId int64
+	stages           []string
+	loggedLines      map[string]int64
+}
+
+func newAllocationProgress(client *kypo.Client, allocationUnitId int64) *allocationProgress {
+	return &allocationProgress{
+		client:           client,
+		allocationUnitId: allocationUnitId,
+		loggedLines:      map[string]int64{},
+	}
+}
+
+// update logs stage transitions since the last update and new output lines of running stages.
+// The remaining output of a stage is logged once when it is seen to finish.
+func (p *allocationProgress) update(ctx context.Context, request kypo.SandboxRequest) {
+	for i, state := range request.Stages {
+		if i >= len(allocationStageNames) {
+			break
+		}
+		previous := ""
+		if i < len(p.stages) {
+			previous = p.stages[i]
+		}
+		if state != previous {
+			tflog.Info(ctx, fmt.Sprintf("sandbox allocation unit %d: %s stage is %s", p.allocationUnitId, allocationStageNames[i], state))
+		}
+		if state == "RUNNING" || (previous != "" && state != previous && (state == "FINISHED" || state == "FAILED")) {
+			p.logOutput(ctx, request.Id, i, state != "RUNNING")
+		}
+	}
+	p.stages = request.Stages
+}
+
+// logOutput logs the output lines of the given stage which were not logged yet. The logged lines are counted by
+// the number of lines of each page reported by KYPO, as a single line of the output may span multiple lines.
+// While the stage runs, only complete pages are logged. The last incomplete page is logged once the stage is over.
+// Errors are only logged, as the output is not available until the stage starts.
+func (p *allocationProgress) logOutput(ctx context.Context, requestId int64, stageIndex int, stageOver bool) {
+	stage := allocationStageNames[stageIndex]
+	for p.loggedLines[stage]%stageOutputPageSize == 0 {
+		page := p.loggedLines[stage]/stageOutputPageSize + 1
+		output, err := p.client.GetSandboxRequestAnsibleOutputs(ctx, requestId, page, stageOutputPageSize, stageOutputTypes[stageIndex])
+		if err != nil {
+			tflog.Debug(ctx, fmt.Sprintf("unable to read output of %s stage of sandbox allocation unit %d, got error: %s", stage, p.allocationUnitId, err))
+			return
+		}
+
+		if output.Count == 0 || (output.Count < stageOutputPageSize && !stageOver) {
+			return
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(output.Result, "\n"), "\n") {
+			tflog.Info(ctx, line, map[string]any{"allocation_unit_id": p.allocationUnitId, "stage": stage})
+		}
+		p.loggedLines[stage] += output.Count
+
+		if page >= output.PageCount {
+			return
+		}
+	}
+}
+
+// awaitAllocationRequest periodically reads the allocation unit until its allocation request is created and finishes.
+// The progress of the allocation is logged on each check, the time between checks is given by the poll strategy.
+func (r *sandboxAllocationUnitResource) awaitAllocationRequest(ctx context.Context, allocationUnitId int64, poll pollStrategy) (*kypo.SandboxRequest, error) {
+	progress := newAllocationProgress(r.client, allocationUnitId)
+	var request kypo.SandboxRequest
+	for checks := 0; ; checks++ {
+		err := wait(ctx, poll.next(checks, request, allocationStageNames))
+		if err != nil {
+			return nil, err
+		}
+
+		allocationUnit, err := r.client.GetSandboxAllocationUnit(ctx, allocationUnitId)
+		if err != nil {
+			return nil, err
+		}
+
+		request = allocationUnit.AllocationRequest
+		// The allocation request is created asynchronously after the allocation unit
+		if len(request.Stages) == 0 {
+			continue
+		}
+
+		progress.update(ctx, request)
+		if !allocationRequestRunning(request) {
+			return &request, nil
+		}
+	}
+}
+
+// cleanup starts the cleanup request of the allocation unit and waits until it finishes, which is when the allocation
+// unit no longer exists. The time between checks is given by the poll strategy.
+func (r *sandboxAllocationUnitResource) cleanup(ctx context.Context, allocationUnitId int64, poll pollStrategy) error {
+	err := r.client.CreateSandboxCleanupRequest(ctx, allocationUnitId)
+	if err != nil {
+		return err
+	}
+
+	var request kypo.SandboxRequest
+	for checks := 0; ; checks++ {
+		err = wait(ctx, poll.next(checks, request, cleanupStageNames))
+		if err != nil {
+			return err
+		}
+
+		allocationUnit, err := r.client.GetSandboxAllocationUnit(ctx, allocationUnitId)
+		if errors.Is(err, kypo.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		request = allocationUnit.CleanupRequest
+		if allocationRequestFailed(request) {
+			return fmt.Errorf("sandbox cleanup request %d of sandbox allocation unit %d finished with error", request.Id, allocationUnitId)
+		}
+	}
+}
+
+// poolFull reports whether the pool is known to have no free slot for another allocation unit.
+func (r *sandboxAllocationUnitResource) poolFull(ctx context.Context, poolId int64) bool {
+	pool, err := r.client.GetSandboxPool(ctx, poolId)
+	return err == nil && pool.Size >= pool.MaxSize
+}
+
+// awaitPoolCapacity periodically reads the pool until it has a free slot for another allocation unit.
+// The pool is checked right away and then once every pollTime elapses.
+func (r *sandboxAllocationUnitResource) awaitPoolCapacity(ctx context.Context, poolId int64, pollTime time.Duration) error {
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+	for {
+		pool, err := r.client.GetSandboxPool(ctx, poolId)
+		if err != nil {
+			return err
+		}
+		if pool.Size < pool.MaxSize {
+			return nil
+		}
+		tflog.Info(ctx, fmt.Sprintf("sandbox pool %d is full with %d of %d allocation units, waiting for a free slot", poolId, pool.Size, pool.MaxSize))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// allocate creates an allocation unit in the given pool and waits until its allocation request finishes.
+// The allocation unit is saved to the state as soon as it is created, together with `allocation_attempts` set to attempt,
+// so that the attempt is counted even when awaiting it fails. Returns nil if an error was added to diagnostics.
+// When capacityPoll is not zero, the allocation unit is created only after the pool has a free slot.
+func (r *sandboxAllocationUnitResource) allocate(ctx context.Context, diagnostics *diag.Diagnostics, state *tfsdk.State, poolId int64,
+	attempt int, poll pollStrategy, capacityPoll time.Duration) *kypo.SandboxAllocationUnit {
+	var allocationUnits []kypo.SandboxAllocationUnit
+	for {
+		if capacityPoll != 0 {
+			err := r.awaitPoolCapacity(ctx, poolId, capacityPoll)
+			if err != nil {
+				diagnostics.AddError("Client Error", fmt.Sprintf("Unable to await free capacity in sandbox pool %d, got error: %s", poolId, err))
+				return nil
+			}
+		}
+
+		var err error
+		allocationUnits, err = r.client.CreateSandboxAllocationUnits(ctx, poolId, 1)
+		if err == nil {
+			break
+		}
+		// Another allocation may have taken the free slot in the meantime
+		if capacityPoll != 0 && r.poolFull(ctx, poolId) {
+			tflog.Info(ctx, fmt.Sprintf("sandbox pool %d became full before the allocation unit was created", poolId))
+			continue
+		}
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create sandbox allocation unit, got error: %s", err))
+		return nil
+	}
+	allocationUnit := allocationUnits[0]
+	setState(ctx, newSandboxAllocationUnitModel(allocationUnit), response{State: state, Diagnostics: diagnostics})
+	diagnostics.Append(state.SetAttribute(ctx, path.Root("allocation_attempts"), attempt)...)
+	if diagnostics.HasError() {
+		return nil
+	}
+
+	allocationRequest, err := r.awaitAllocationRequest(ctx, allocationUnit.Id, poll)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation request failed, got error: %s", err))
+		return nil
+	}
+	allocationUnit.AllocationRequest = *allocationRequest
+	setState(ctx, newSandboxAllocationUnitModel(allocationUnit), response{State: state, Diagnostics: diagnostics})
+	if diagnostics.HasError() {
+		return nil
+	}
+	return &allocationUnit
+}
+
+// handleCreateTimeout handles the expiry of the create timeout after an allocation unit was saved to the state.
+// With `on_timeout` set to `cancel_and_cleanup`, the allocation request is cancelled, the allocation unit is cleaned up
+// and removed from the state. Otherwise, the allocation unit is kept in the state.
+func (r *sandboxAllocationUnitResource) handleCreateTimeout(ctx context.Context, diagnostics *diag.Diagnostics, state *tfsdk.State,
+	onTimeout types.String, timeoutsValue timeouts.Value, poll pollStrategy) {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+
+	var id types.Int64
+	diagnostics.Append(state.GetAttribute(ctx, path.Root("id"), &id)...)
+	if diagnostics.HasError() || id.IsNull() {
+		return
+	}
+
+	if onTimeout.ValueString() != "cancel_and_cleanup" {
+		diagnostics.AddError("Sandbox Allocation Timed Out",
+			fmt.Sprintf("The create timeout expired before the allocation of sandbox allocation unit %d finished. "+
+				"The allocation continues in KYPO and the allocation unit is kept in the state", id.ValueInt64()))
+		return
+	}
+
+	// The create timeout has already expired, the cleanup is bounded by the delete timeout instead.
+	ctx, cancel := setTimeout(diagnostics, context.WithoutCancel(ctx), timeoutsValue, "delete")
+	defer cancel()
+
+	allocationUnit, err := r.client.GetSandboxAllocationUnit(ctx, id.ValueInt64())
+	if errors.Is(err, kypo.ErrNotFound) {
+		state.RemoveResource(ctx)
+		diagnostics.AddError("Sandbox Allocation Timed Out",
+			fmt.Sprintf("The create timeout expired before the allocation of sandbox allocation unit %d finished. "+
+				"The allocation unit no longer exists", id.ValueInt64()))
+		return
+	}
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation unit %d after the create timeout expired, "+
+			"the allocation unit is kept in the state, got error: %s", id.ValueInt64(), err))
+		return
+	}
+
+	if allocationRequestRunning(allocationUnit.AllocationRequest) {
+		err = r.client.CancelSandboxAllocationRequest(ctx, allocationUnit.AllocationRequest.Id)
+		if err != nil {
+			diagnostics.AddError("Client Error", fmt.Sprintf("Unable to cancel allocation request %d after the create timeout expired, "+
+				"the allocation unit %d is kept in the state, got error: %s", allocationUnit.AllocationRequest.Id, id.ValueInt64(), err))
+			return
+		}
+	}
+
+	err = r.cleanup(ctx, id.ValueInt64(), poll)
+	if err != nil && !errors.Is(err, kypo.ErrNotFound) {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox allocation unit %d after the create timeout expired, "+
+			"the allocation unit is kept in the state, got error: %s", id.ValueInt64(), err))
+		return
+	}
+
+	state.RemoveResource(ctx)
+	diagnostics.AddError("Sandbox Allocation Timed Out",
+		fmt.Sprintf("The create timeout expired before the allocation of sandbox allocation unit %d finished. "+
+			"The allocation request was cancelled and the allocation unit was cleaned up", id.ValueInt64()))
+}
+
 func (r *sandboxAllocationUnitResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var poolId types.Int64
 	var timeoutsValue timeouts.Value
 	var pollTimes types.Object
+	var polling types.Object
 
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("pool_id"), &poolId)...)
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("polling"), &polling)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("polling"), polling)...)
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -291,36 +921,91 @@ func (r *sandboxAllocationUnitResource) Create(ctx context.Context, req resource
 	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "create")
 	defer cancel()
 
-	pollTimeCreate := getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second)
+	pollCreate := getPollStrategy(&resp.Diagnostics, ctx, polling, getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second))
+	pollDelete := getPollStrategy(&resp.Diagnostics, ctx, polling, getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second))
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	allocationUnits, err := r.client.CreateSandboxAllocationUnits(ctx, poolId.ValueInt64(), 1)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create sandbox allocation unit, got error: %s", err))
-		return
-	}
-	allocationUnit := allocationUnits[0]
-	setState(ctx, allocationUnit, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+	var allocationRetries types.Int64
+	var retryOnStages []string
+	var preventDestroyWhenLocked, forceDestroy types.Bool
+	var onTimeout types.String
+	var onFailedStage types.String
+	var waitForCapacity types.Bool
+	var capacityPoll types.String
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("wait_for_capacity"), &waitForCapacity)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("wait_for_capacity"), waitForCapacity)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("capacity_poll"), &capacityPoll)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("capacity_poll"), capacityPoll)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_timeout"), &onTimeout)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on_timeout"), onTimeout)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_failed_stage"), &onFailedStage)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on_failed_stage"), onFailedStage)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("prevent_destroy_when_locked"), preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("force_destroy"), forceDestroy)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("allocation_retries"), &allocationRetries)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_retries"), allocationRetries)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("retry_on_stages"), &retryOnStages)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("retry_on_stages"), retryOnStages)...)
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	err = r.client.AwaitAllocationRequestCreate(ctx, allocationUnit.Id, pollTimeCreate)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create sandbox allocation request, got error: %s", err))
-		return
+	if retryOnStages == nil {
+		retryOnStages = allocationStageNames
 	}
 
-	allocationRequest, err := r.client.PollRequestFinished(ctx, allocationUnit.Id, pollTimeCreate, "allocation")
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation request failed, got error: %s", err))
-		return
+	var capacityPollTime time.Duration
+	if waitForCapacity.ValueBool() {
+		capacityPollTime = 30 * time.Second
+		if !capacityPoll.IsNull() {
+			// The value is already checked by the TimeDuration validator
+			capacityPollTime, _ = time.ParseDuration(capacityPoll.ValueString())
+		}
 	}
-	allocationUnit.AllocationRequest = *allocationRequest
-	setState(ctx, allocationUnit, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+
+	var allocationUnit *kypo.SandboxAllocationUnit
+	allocationFailures := []allocationFailureModel{}
+	for {
+		allocationUnit = r.allocate(ctx, &resp.Diagnostics, &resp.State, poolId.ValueInt64(), len(allocationFailures)+1, pollCreate, capacityPollTime)
+		if allocationUnit == nil {
+			r.handleCreateTimeout(ctx, &resp.Diagnostics, &resp.State, onTimeout, timeoutsValue, pollDelete)
+			return
+		}
+
+		stage := failedStage(allocationUnit.AllocationRequest, allocationStageNames)
+		if stage == "" || int64(len(allocationFailures)) >= allocationRetries.ValueInt64() || !slices.Contains(retryOnStages, stage) {
+			break
+		}
+
+		allocationFailures = append(allocationFailures, allocationFailureModel{
+			AllocationUnitId:    allocationUnit.Id,
+			AllocationRequestId: allocationUnit.AllocationRequest.Id,
+			FailedStage:         stage,
+		})
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_failures"), allocationFailures)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		tflog.Warn(ctx, fmt.Sprintf("allocation of sandbox allocation unit %d failed in %s stage, cleaning it up and retrying (retry %d of %d)",
+			allocationUnit.Id, stage, len(allocationFailures), allocationRetries.ValueInt64()))
+
+		err := r.cleanup(ctx, allocationUnit.Id, pollDelete)
+		if err != nil && !errors.Is(err, kypo.ErrNotFound) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up failed sandbox allocation unit %d before retrying, got error: %s", allocationUnit.Id, err))
+			r.handleCreateTimeout(ctx, &resp.Diagnostics, &resp.State, onTimeout, timeoutsValue, pollDelete)
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_failures"), allocationFailures)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -336,7 +1021,7 @@ func (r *sandboxAllocationUnitResource) Create(ctx context.Context, req resource
 		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("warning_on_allocation_failure"), warningOnAllocationFailureBool)...)
 	}
 
-	checkAllocationRequestResult(&allocationUnit, &resp.Diagnostics, warningOnAllocationFailureBool, allocationUnit.Id)
+	checkAllocationRequestResult(allocationUnit, &resp.Diagnostics, warningOnAllocationFailureBool, allocationUnit.Id)
 
 	// Write logs using the tflog package
 	// Documentation: https://terraform.io/plugin/log
@@ -346,9 +1031,13 @@ func (r *sandboxAllocationUnitResource) Create(ctx context.Context, req resource
 func (r *sandboxAllocationUnitResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var id types.Int64
 	var timeoutsValue timeouts.Value
+	var stages []string
+	var onFailedStage types.String
 
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_request").AtName("stages"), &stages)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("on_failed_stage"), &onFailedStage)...)
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -374,7 +1063,14 @@ func (r *sandboxAllocationUnitResource) Read(ctx context.Context, req resource.R
 		return
 	}
 
-	setState(ctx, *allocationUnit, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+	setState(ctx, newSandboxAllocationUnitModel(*allocationUnit), response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+
+	// A failed stage, which is kept because of `on_failed_stage`, is reported once, when it first appears in the state
+	stage := failedStage(allocationUnit.AllocationRequest, allocationStageNames)
+	if onFailedStage.ValueString() == "ignore" && stage != "" && !slices.Contains(stages, "FAILED") {
+		resp.Diagnostics.AddAttributeWarning(path.Root("allocation_request").AtName("stages"), "Failed Allocation Stage Ignored",
+			fmt.Sprintf("The %s stage of the allocation request failed, the allocation unit is kept because `on_failed_stage` is `ignore`", stage))
+	}
 }
 
 func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
@@ -383,6 +1079,14 @@ func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource
 	var planAllocationRequest types.Object
 	var timeoutsValue timeouts.Value
 	var pollTimes types.Object
+	var polling types.Object
+	var allocationRetries types.Int64
+	var retryOnStages types.List
+	var preventDestroyWhenLocked, forceDestroy types.Bool
+	var onTimeout types.String
+	var onFailedStage types.String
+	var waitForCapacity types.Bool
+	var capacityPoll types.String
 
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("warning_on_allocation_failure"), &stateWarningOnAllocationFailure)...)
@@ -392,6 +1096,24 @@ func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("polling"), &polling)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("polling"), polling)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("allocation_retries"), &allocationRetries)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_retries"), allocationRetries)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("retry_on_stages"), &retryOnStages)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("retry_on_stages"), retryOnStages)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("prevent_destroy_when_locked"), preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("force_destroy"), forceDestroy)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_timeout"), &onTimeout)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on_timeout"), onTimeout)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_failed_stage"), &onFailedStage)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("on_failed_stage"), onFailedStage)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("wait_for_capacity"), &waitForCapacity)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("wait_for_capacity"), waitForCapacity)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("capacity_poll"), &capacityPoll)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("capacity_poll"), capacityPoll)...)
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -400,7 +1122,7 @@ func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource
 	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "update")
 	defer cancel()
 
-	pollTimeUpdate := getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second)
+	pollUpdate := getPollStrategy(&resp.Diagnostics, ctx, polling, getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second))
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -424,13 +1146,13 @@ func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource
 		return
 	}
 
-	allocationRequest, err := r.client.PollRequestFinished(ctx, allocationUnit.AllocationRequest.Id, pollTimeUpdate, "allocation")
+	allocationRequest, err := r.awaitAllocationRequest(ctx, allocationUnit.Id, pollUpdate)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation request failed, got error: %s", err))
 		return
 	}
 	allocationUnit.AllocationRequest = *allocationRequest
-	setState(ctx, *allocationUnit, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+	setState(ctx, newSandboxAllocationUnitModel(*allocationUnit), response{State: &resp.State, Diagnostics: &resp.Diagnostics})
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -441,15 +1163,20 @@ func (r *sandboxAllocationUnitResource) Update(ctx context.Context, req resource
 }
 
 func (r *sandboxAllocationUnitResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	var allocationRequest *kypo.SandboxRequest
+	var allocationRequest *sandboxRequestModel
 	var id types.Int64
 	var timeoutsValue timeouts.Value
 	var pollTimes types.Object
+	var polling types.Object
+	var preventDestroyWhenLocked, forceDestroy types.Bool
 
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_request"), &allocationRequest)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("polling"), &polling)...)
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -458,12 +1185,30 @@ func (r *sandboxAllocationUnitResource) Delete(ctx context.Context, req resource
 	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "delete")
 	defer cancel()
 
-	pollTimeDelete := getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second)
+	pollDelete := getPollStrategy(&resp.Diagnostics, ctx, polling, getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second))
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if preventDestroyWhenLocked.ValueBool() || preventDestroyWhenLocked.IsNull() {
+		allocationUnit, err := r.client.GetSandboxAllocationUnit(ctx, id.ValueInt64())
+		if errors.Is(err, kypo.ErrNotFound) {
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation unit, got error: %s", err))
+			return
+		}
+		if allocationUnit.Locked && !forceDestroy.ValueBool() {
+			resp.Diagnostics.AddError("Locked Sandbox Allocation Unit",
+				fmt.Sprintf("Sandbox allocation unit %d is locked, it is claimed by a trainee and used by a training run. "+
+					"Destroying it would destroy the sandbox of the trainee. Finish or delete the training run, "+
+					"or apply `force_destroy = true` or `prevent_destroy_when_locked = false` first to destroy it anyway", id.ValueInt64()))
+			return
+		}
+	}
+
 	if slices.Contains(allocationRequest.Stages, "RUNNING") {
 		err := r.client.CancelSandboxAllocationRequest(ctx, allocationRequest.Id)
 		if err != nil {
@@ -472,7 +1217,7 @@ func (r *sandboxAllocationUnitResource) Delete(ctx context.Context, req resource
 		}
 	}
 
-	err := r.client.CreateSandboxCleanupRequestAwait(ctx, id.ValueInt64(), pollTimeDelete)
+	err := r.cleanup(ctx, id.ValueInt64(), pollDelete)
 	if errors.Is(err, kypo.ErrNotFound) {
 		return
 	}
@@ -482,6 +1227,61 @@ func (r *sandboxAllocationUnitResource) Delete(ctx context.Context, req resource
 	}
 }
 
+// requiresReplace reports whether the planned change of an existing allocation unit replaces it.
+func requiresReplace(ctx context.Context, state tfsdk.State, plan tfsdk.Plan) (bool, diag.Diagnostics) {
+	var statePoolId, planPoolId types.Int64
+	var stages []string
+	var onFailedStage types.String
+	var diags diag.Diagnostics
+
+	diags.Append(state.GetAttribute(ctx, path.Root("pool_id"), &statePoolId)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("pool_id"), &planPoolId)...)
+	diags.Append(state.GetAttribute(ctx, path.Root("allocation_request").AtName("stages"), &stages)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("on_failed_stage"), &onFailedStage)...)
+	if diags.HasError() {
+		return false, diags
+	}
+
+	failedStageReplaced := slices.Contains(stages, "FAILED") && onFailedStage.ValueString() != "ignore"
+	return !statePoolId.Equal(planPoolId) || failedStageReplaced, diags
+}
+
+// ModifyPlan warns when a locked allocation unit is about to be destroyed or replaced.
+func (r *sandboxAllocationUnitResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var id types.Int64
+	var locked, preventDestroyWhenLocked, forceDestroy types.Bool
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("locked"), &locked)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
+
+	if resp.Diagnostics.HasError() || !locked.ValueBool() {
+		return
+	}
+
+	action := "destroyed"
+	if !req.Plan.Raw.IsNull() {
+		replace, diags := requiresReplace(ctx, req.State, req.Plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() || !replace {
+			return
+		}
+		action = "replaced"
+	}
+
+	detail := fmt.Sprintf("Sandbox allocation unit %d is locked, it is claimed by a trainee and used by a training run. "+
+		"It is about to be %s, which destroys the sandbox of the trainee.", id.ValueInt64(), action)
+	if (preventDestroyWhenLocked.ValueBool() || preventDestroyWhenLocked.IsNull()) && !forceDestroy.ValueBool() {
+		detail += " The apply will fail unless `force_destroy = true` or `prevent_destroy_when_locked = false` is applied first."
+	}
+	resp.Diagnostics.AddWarning("Locked Sandbox Allocation Unit", detail)
+}
+
 func (r *sandboxAllocationUnitResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	id, err := strconv.Atoi(req.ID)
 	if err != nil {
@@ -0,0 +1,782 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+func TestNewSandboxAllocationUnitModelStageDetails(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		allocationUnit          kypo.SandboxAllocationUnit
+		expectAllocationDetails map[string]stageDetailModel
+		expectCleanupDetails    map[string]stageDetailModel
+	}{
+		"allocation-running": {
+			allocationUnit: kypo.SandboxAllocationUnit{
+				AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "RUNNING", "IN_QUEUE"}},
+			},
+			expectAllocationDetails: map[string]stageDetailModel{
+				"terraform":          {State: "FINISHED"},
+				"networking_ansible": {State: "RUNNING"},
+				"user_ansible":       {State: "IN_QUEUE"},
+			},
+			expectCleanupDetails: map[string]stageDetailModel{},
+		},
+		"cleanup-running": {
+			allocationUnit: kypo.SandboxAllocationUnit{
+				AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED"}},
+				CleanupRequest:    kypo.SandboxRequest{Stages: []string{"FINISHED", "RUNNING", "IN_QUEUE"}},
+			},
+			expectAllocationDetails: map[string]stageDetailModel{
+				"terraform":          {State: "FINISHED"},
+				"networking_ansible": {State: "FINISHED"},
+				"user_ansible":       {State: "FINISHED"},
+			},
+			// The cleanup stages are listed in reverse order of the allocation stages
+			expectCleanupDetails: map[string]stageDetailModel{
+				"user_ansible":       {State: "FINISHED"},
+				"networking_ansible": {State: "RUNNING"},
+				"terraform":          {State: "IN_QUEUE"},
+			},
+		},
+		"unexpected-stages-ignored": {
+			allocationUnit: kypo.SandboxAllocationUnit{
+				AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED", "FAILED"}},
+			},
+			expectAllocationDetails: map[string]stageDetailModel{
+				"terraform":          {State: "FINISHED"},
+				"networking_ansible": {State: "FINISHED"},
+				"user_ansible":       {State: "FINISHED"},
+			},
+			expectCleanupDetails: map[string]stageDetailModel{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			model := newSandboxAllocationUnitModel(testCase.allocationUnit)
+
+			if diff := cmp.Diff(model.AllocationRequest.StageDetails, testCase.expectAllocationDetails); diff != "" {
+				t.Errorf("unexpected allocation request stage details difference: %s", diff)
+			}
+			if diff := cmp.Diff(model.CleanupRequest.StageDetails, testCase.expectCleanupDetails); diff != "" {
+				t.Errorf("unexpected cleanup request stage details difference: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourceAllocateCountsAttempt(t *testing.T) {
+	t.Parallel()
+
+	fake, client := newFakeKypo(t)
+	fake.pools[7] = kypo.SandboxPool{Id: 7, MaxSize: 1}
+
+	r := &sandboxAllocationUnitResource{client: client}
+	state := newTestState(t, r, map[string]any{})
+	var diags diag.Diagnostics
+
+	// The allocation never finishes, so awaiting it ends with the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	poll := pollStrategy{strategy: "fixed", min: time.Millisecond, max: time.Millisecond, multiplier: 1}
+
+	allocationUnit := r.allocate(ctx, &diags, &state, 7, 2, poll, 0)
+
+	if allocationUnit != nil || !diags.HasError() {
+		t.Fatalf("expected the allocation to time out, got %v and diagnostics: %v", allocationUnit, diags)
+	}
+
+	var id, attempts types.Int64
+	state.GetAttribute(context.Background(), path.Root("id"), &id)
+	state.GetAttribute(context.Background(), path.Root("allocation_attempts"), &attempts)
+	if id.ValueInt64() != fake.lastId {
+		t.Errorf("expected the allocation unit %d to be saved to the state, got %s", fake.lastId, id)
+	}
+	if !attempts.Equal(types.Int64Value(2)) {
+		t.Errorf("expected the attempt in progress to be counted, got %s", attempts)
+	}
+}
+
+func TestAllocationProgressLogOutput(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	fake, client := newFakeKypo(t)
+	progress := newAllocationProgress(client, 1)
+
+	// A single output line may span multiple lines
+	lines := make([]string, stageOutputPageSize+5)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\ncontinued", i)
+	}
+
+	steps := []struct {
+		available   int
+		stageOver   bool
+		expectLines int64
+	}{
+		// Incomplete pages of a running stage are not logged yet
+		{available: 5, expectLines: 0},
+		{available: stageOutputPageSize + 3, expectLines: stageOutputPageSize},
+		{available: stageOutputPageSize + 4, expectLines: stageOutputPageSize},
+		// The rest is logged once the stage is over
+		{available: stageOutputPageSize + 5, stageOver: true, expectLines: stageOutputPageSize + 5},
+	}
+	for i, step := range steps {
+		fake.mu.Lock()
+		fake.outputs["3/terraform"] = lines[:step.available]
+		fake.mu.Unlock()
+
+		progress.logOutput(ctx, 3, 0, step.stageOver)
+
+		if got := progress.loggedLines["terraform"]; got != step.expectLines {
+			t.Errorf("step %d: expected %d logged lines, got %d", i, step.expectLines, got)
+		}
+	}
+}
+
+func TestSandboxAllocationUnitResourceDeleteLocked(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		locked                   bool
+		preventDestroyWhenLocked any
+		forceDestroy             any
+		expectError              bool
+	}{
+		"unlocked": {},
+		"locked-prevent-default": {
+			locked:      true,
+			expectError: true,
+		},
+		"locked-prevent": {
+			locked:                   true,
+			preventDestroyWhenLocked: true,
+			expectError:              true,
+		},
+		"locked-not-prevented": {
+			locked:                   true,
+			preventDestroyWhenLocked: false,
+		},
+		"locked-force-destroy": {
+			locked:       true,
+			forceDestroy: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.units[1] = kypo.SandboxAllocationUnit{Id: 1, PoolId: 7, Locked: testCase.locked}
+
+			r := &sandboxAllocationUnitResource{client: client}
+			attributes := map[string]any{
+				"id":                 int64(1),
+				"pool_id":            int64(7),
+				"allocation_request": newSandboxRequestModel(kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED"}}, allocationStageNames),
+				"poll_times":         testPollTimes,
+			}
+			if testCase.preventDestroyWhenLocked != nil {
+				attributes["prevent_destroy_when_locked"] = testCase.preventDestroyWhenLocked
+			}
+			if testCase.forceDestroy != nil {
+				attributes["force_destroy"] = testCase.forceDestroy
+			}
+			state := newTestState(t, r, attributes)
+			resp := resource.DeleteResponse{State: state}
+
+			r.Delete(ctx, resource.DeleteRequest{State: state}, &resp)
+
+			if resp.Diagnostics.HasError() != testCase.expectError {
+				t.Fatalf("expected error %t, got diagnostics: %v", testCase.expectError, resp.Diagnostics)
+			}
+			if _, exists := fake.unit(1); exists != testCase.expectError {
+				t.Errorf("expected the allocation unit to be kept %t, got requests: %v", testCase.expectError, fake.requested())
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourceModifyPlanLocked(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		locked       bool
+		forceDestroy any
+		destroy      bool
+		planPoolId   int64
+		expectDetail string
+	}{
+		"unlocked-destroy": {
+			destroy: true,
+		},
+		"locked-destroy": {
+			locked:  true,
+			destroy: true,
+			expectDetail: "Sandbox allocation unit 1 is locked, it is claimed by a trainee and used by a training run. " +
+				"It is about to be destroyed, which destroys the sandbox of the trainee. " +
+				"The apply will fail unless `force_destroy = true` or `prevent_destroy_when_locked = false` is applied first.",
+		},
+		"locked-destroy-force": {
+			locked:       true,
+			forceDestroy: true,
+			destroy:      true,
+			expectDetail: "Sandbox allocation unit 1 is locked, it is claimed by a trainee and used by a training run. " +
+				"It is about to be destroyed, which destroys the sandbox of the trainee.",
+		},
+		"locked-replace": {
+			locked:     true,
+			planPoolId: 8,
+			expectDetail: "Sandbox allocation unit 1 is locked, it is claimed by a trainee and used by a training run. " +
+				"It is about to be replaced, which destroys the sandbox of the trainee. " +
+				"The apply will fail unless `force_destroy = true` or `prevent_destroy_when_locked = false` is applied first.",
+		},
+		"locked-update": {
+			locked:     true,
+			planPoolId: 7,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			r := &sandboxAllocationUnitResource{}
+			attributes := map[string]any{
+				"id":                 int64(1),
+				"pool_id":            int64(7),
+				"locked":             testCase.locked,
+				"allocation_request": newSandboxRequestModel(kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED"}}, allocationStageNames),
+			}
+			if testCase.forceDestroy != nil {
+				attributes["force_destroy"] = testCase.forceDestroy
+			}
+			state := newTestState(t, r, attributes)
+
+			plan := tfsdk.Plan{Schema: state.Schema, Raw: tftypes.NewValue(state.Schema.Type().TerraformType(ctx), nil)}
+			if !testCase.destroy {
+				plan.Raw = state.Raw.Copy()
+				plan.SetAttribute(ctx, path.Root("pool_id"), testCase.planPoolId)
+			}
+			resp := resource.ModifyPlanResponse{Plan: plan}
+
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{State: state, Plan: plan}, &resp)
+
+			var expectDiagnostics diag.Diagnostics
+			if testCase.expectDetail != "" {
+				expectDiagnostics.AddWarning("Locked Sandbox Allocation Unit", testCase.expectDetail)
+			}
+			if diff := cmp.Diff(resp.Diagnostics, expectDiagnostics); diff != "" {
+				t.Errorf("unexpected diagnostics difference: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourceReadFailedStageIgnored(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		onFailedStage string
+		stateStages   []string
+		expectWarning bool
+	}{
+		"failure-appears": {
+			onFailedStage: "ignore",
+			stateStages:   []string{"FINISHED", "RUNNING", "IN_QUEUE"},
+			expectWarning: true,
+		},
+		"failure-in-state": {
+			onFailedStage: "ignore",
+			stateStages:   []string{"FINISHED", "FAILED", "IN_QUEUE"},
+		},
+		"failure-replaced": {
+			onFailedStage: "replace",
+			stateStages:   []string{"FINISHED", "RUNNING", "IN_QUEUE"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.units[1] = kypo.SandboxAllocationUnit{Id: 1, PoolId: 7,
+				AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "FAILED", "IN_QUEUE"}}}
+
+			r := &sandboxAllocationUnitResource{client: client}
+			state := newTestState(t, r, map[string]any{
+				"id":                 int64(1),
+				"pool_id":            int64(7),
+				"on_failed_stage":    testCase.onFailedStage,
+				"allocation_request": newSandboxRequestModel(kypo.SandboxRequest{Stages: testCase.stateStages}, allocationStageNames),
+			})
+			resp := resource.ReadResponse{State: state}
+
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+			var expectDiagnostics diag.Diagnostics
+			if testCase.expectWarning {
+				expectDiagnostics.AddAttributeWarning(path.Root("allocation_request").AtName("stages"), "Failed Allocation Stage Ignored",
+					"The networking_ansible stage of the allocation request failed, the allocation unit is kept because `on_failed_stage` is `ignore`")
+			}
+			if diff := cmp.Diff(resp.Diagnostics, expectDiagnostics); diff != "" {
+				t.Errorf("unexpected diagnostics difference: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourceHandleCreateTimeout(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		onTimeout      string
+		notExpired     bool
+		unitExists     bool
+		requestStages  []string
+		expectError    bool
+		expectRemoved  bool
+		expectRequests []string
+	}{
+		"not-expired": {
+			onTimeout:     "cancel_and_cleanup",
+			notExpired:    true,
+			unitExists:    true,
+			requestStages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"},
+		},
+		"keep": {
+			onTimeout:     "keep",
+			unitExists:    true,
+			requestStages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"},
+			expectError:   true,
+		},
+		"cancel-and-cleanup-running": {
+			onTimeout:     "cancel_and_cleanup",
+			unitExists:    true,
+			requestStages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"},
+			expectError:   true,
+			expectRemoved: true,
+			expectRequests: []string{
+				"GET /sandbox-allocation-units/1",
+				"PATCH /allocation-requests/2/cancel",
+				"POST /sandbox-allocation-units/1/cleanup-request",
+				"GET /sandbox-allocation-units/1",
+			},
+		},
+		"cancel-and-cleanup-finished": {
+			onTimeout:     "cancel_and_cleanup",
+			unitExists:    true,
+			requestStages: []string{"FINISHED", "FINISHED", "FINISHED"},
+			expectError:   true,
+			expectRemoved: true,
+			expectRequests: []string{
+				"GET /sandbox-allocation-units/1",
+				"POST /sandbox-allocation-units/1/cleanup-request",
+				"GET /sandbox-allocation-units/1",
+			},
+		},
+		"cancel-and-cleanup-gone": {
+			onTimeout:      "cancel_and_cleanup",
+			expectError:    true,
+			expectRemoved:  true,
+			expectRequests: []string{"GET /sandbox-allocation-units/1"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fake, client := newFakeKypo(t)
+			if testCase.unitExists {
+				fake.units[1] = kypo.SandboxAllocationUnit{
+					Id: 1, PoolId: 7, AllocationRequest: kypo.SandboxRequest{Id: 2, AllocationUnitId: 1, Stages: testCase.requestStages},
+				}
+			}
+
+			r := &sandboxAllocationUnitResource{client: client}
+			state := newTestState(t, r, map[string]any{"id": int64(1), "pool_id": int64(7)})
+			var diags diag.Diagnostics
+
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+			if testCase.notExpired {
+				ctx = context.Background()
+			}
+			poll := pollStrategy{strategy: "fixed", min: time.Millisecond, max: time.Millisecond, multiplier: 1}
+
+			r.handleCreateTimeout(ctx, &diags, &state, types.StringValue(testCase.onTimeout), timeouts.Value{}, poll)
+
+			if diags.HasError() != testCase.expectError {
+				t.Errorf("expected error %t, got diagnostics: %v", testCase.expectError, diags)
+			}
+			if state.Raw.IsNull() != testCase.expectRemoved {
+				t.Errorf("expected the allocation unit to be removed from the state %t, got state: %v", testCase.expectRemoved, state.Raw)
+			}
+			if diff := cmp.Diff(fake.requested(), testCase.expectRequests); diff != "" {
+				t.Errorf("unexpected requests difference: %s", diff)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourcePoolFull(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		pool       *kypo.SandboxPool
+		expectFull bool
+	}{
+		"full": {
+			pool:       &kypo.SandboxPool{Id: 7, Size: 2, MaxSize: 2},
+			expectFull: true,
+		},
+		"free-slot": {
+			pool: &kypo.SandboxPool{Id: 7, Size: 1, MaxSize: 2},
+		},
+		// An error while reading the pool is not reported as a full pool, so that the original error is reported instead
+		"missing": {},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fake, client := newFakeKypo(t)
+			if testCase.pool != nil {
+				fake.pools[7] = *testCase.pool
+			}
+
+			r := &sandboxAllocationUnitResource{client: client}
+			if full := r.poolFull(context.Background(), 7); full != testCase.expectFull {
+				t.Errorf("expected pool full %t, got %t", testCase.expectFull, full)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitResourceAwaitPoolCapacity(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slot-freed", func(t *testing.T) {
+		t.Parallel()
+
+		fake, client := newFakeKypo(t)
+		fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 2, MaxSize: 2}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 1, MaxSize: 2}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := &sandboxAllocationUnitResource{client: client}
+		if err := r.awaitPoolCapacity(ctx, 7, time.Millisecond); err != nil {
+			t.Errorf("expected the free slot to be awaited, got error: %s", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		fake, client := newFakeKypo(t)
+		fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 2, MaxSize: 2}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		r := &sandboxAllocationUnitResource{client: client}
+		if err := r.awaitPoolCapacity(ctx, 7, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the timeout to expire, got error: %v", err)
+		}
+	})
+}
+
+func TestSandboxAllocationUnitResourceAllocateFullPool(t *testing.T) {
+	t.Parallel()
+
+	fake, client := newFakeKypo(t)
+	fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 1, MaxSize: 1}
+
+	r := &sandboxAllocationUnitResource{client: client}
+	state := newTestState(t, r, map[string]any{})
+	var diags diag.Diagnostics
+	poll := pollStrategy{strategy: "fixed", min: time.Millisecond, max: time.Millisecond, multiplier: 1}
+
+	// Without waiting for capacity, a full pool fails the allocation right away
+	allocationUnit := r.allocate(context.Background(), &diags, &state, 7, 1, poll, 0)
+
+	if allocationUnit != nil || !diags.HasError() {
+		t.Fatalf("expected the allocation to fail, got %v and diagnostics: %v", allocationUnit, diags)
+	}
+	if !state.Raw.IsNull() {
+		t.Errorf("expected no allocation unit in the state, got state: %v", state.Raw)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.units) != 0 {
+		t.Errorf("expected no allocation unit to be created, got %v", fake.units)
+	}
+}
+
+func TestSandboxAllocationUnitResourceAllocateWaitForCapacity(t *testing.T) {
+	t.Parallel()
+
+	fake, client := newFakeKypo(t)
+	fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 1, MaxSize: 1}
+	fake.allocationStages = []string{"FINISHED", "FINISHED", "FINISHED"}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 0, MaxSize: 1}
+	}()
+
+	r := &sandboxAllocationUnitResource{client: client}
+	state := newTestState(t, r, map[string]any{})
+	var diags diag.Diagnostics
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	poll := pollStrategy{strategy: "fixed", min: time.Millisecond, max: time.Millisecond, multiplier: 1}
+
+	allocationUnit := r.allocate(ctx, &diags, &state, 7, 1, poll, time.Millisecond)
+
+	if allocationUnit == nil || diags.HasError() {
+		t.Fatalf("expected the allocation to wait for the free slot, got diagnostics: %v", diags)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if allocationUnit.Id != fake.lastId {
+		t.Errorf("expected allocation unit %d, got %d", fake.lastId, allocationUnit.Id)
+	}
+}
+
+// testPolling is the `polling` attribute of the sandbox allocation unit resource.
+type testPolling struct {
+	Strategy   types.String  `tfsdk:"strategy"`
+	Min        types.String  `tfsdk:"min"`
+	Max        types.String  `tfsdk:"max"`
+	Multiplier types.Float64 `tfsdk:"multiplier"`
+	Jitter     types.Float64 `tfsdk:"jitter"`
+}
+
+func TestGetPollStrategy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		polling *testPolling
+		expect  pollStrategy
+	}{
+		"null": {
+			expect: pollStrategy{strategy: "fixed", min: 10 * time.Second, max: 2 * time.Minute, multiplier: 2},
+		},
+		"fixed-ignores-min": {
+			polling: &testPolling{Strategy: types.StringValue("fixed"), Min: types.StringValue("1s"), Max: types.StringNull(),
+				Multiplier: types.Float64Null(), Jitter: types.Float64Value(0.1)},
+			expect: pollStrategy{strategy: "fixed", min: 10 * time.Second, max: 2 * time.Minute, multiplier: 2, jitter: 0.1},
+		},
+		"exponential": {
+			polling: &testPolling{Strategy: types.StringValue("exponential"), Min: types.StringValue("1s"), Max: types.StringValue("1m"),
+				Multiplier: types.Float64Value(3), Jitter: types.Float64Null()},
+			expect: pollStrategy{strategy: "exponential", min: time.Second, max: time.Minute, multiplier: 3},
+		},
+		"exponential-default-min": {
+			polling: &testPolling{Strategy: types.StringValue("exponential"), Min: types.StringNull(), Max: types.StringNull(),
+				Multiplier: types.Float64Null(), Jitter: types.Float64Null()},
+			expect: pollStrategy{strategy: "exponential", min: 10 * time.Second, max: 2 * time.Minute, multiplier: 2},
+		},
+		"stage-aware-max-below-min": {
+			polling: &testPolling{Strategy: types.StringValue("stage_aware"), Min: types.StringNull(), Max: types.StringValue("1s"),
+				Multiplier: types.Float64Null(), Jitter: types.Float64Null()},
+			expect: pollStrategy{strategy: "stage_aware", min: 10 * time.Second, max: 10 * time.Second, multiplier: 2},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			attributes := map[string]any{}
+			if testCase.polling != nil {
+				attributes["polling"] = testCase.polling
+			}
+			state := newTestState(t, &sandboxAllocationUnitResource{}, attributes)
+			var polling types.Object
+			state.GetAttribute(ctx, path.Root("polling"), &polling)
+			var diags diag.Diagnostics
+
+			poll := getPollStrategy(&diags, ctx, polling, 10*time.Second)
+
+			if diags.HasError() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if diff := cmp.Diff(poll, testCase.expect, cmp.AllowUnexported(pollStrategy{})); diff != "" {
+				t.Errorf("unexpected poll strategy difference: %s", diff)
+			}
+		})
+	}
+}
+
+func TestPollStrategyNext(t *testing.T) {
+	t.Parallel()
+
+	terraformRunning := kypo.SandboxRequest{Stages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"}}
+
+	testCases := map[string]struct {
+		poll    pollStrategy
+		request kypo.SandboxRequest
+		// expect holds the expected times for checks 0, 1, 2, ...
+		expect []time.Duration
+	}{
+		"fixed": {
+			poll:    pollStrategy{strategy: "fixed", min: time.Second, max: time.Minute, multiplier: 2},
+			request: terraformRunning,
+			expect:  []time.Duration{time.Second, time.Second, time.Second, time.Second},
+		},
+		"exponential": {
+			poll:   pollStrategy{strategy: "exponential", min: time.Second, max: time.Minute, multiplier: 3},
+			expect: []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 27 * time.Second},
+		},
+		"exponential-max": {
+			poll:   pollStrategy{strategy: "exponential", min: time.Second, max: 10 * time.Second, multiplier: 2},
+			expect: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for checks, expect := range testCase.expect {
+				if got := testCase.poll.next(checks, testCase.request, allocationStageNames); got != expect {
+					t.Errorf("check %d: expected %s, got %s", checks, expect, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPollStrategyNextStageAware(t *testing.T) {
+	t.Parallel()
+
+	poll := pollStrategy{strategy: "stage_aware", min: time.Second, max: time.Minute, multiplier: 2}
+
+	type check struct {
+		stages []string
+		expect time.Duration
+	}
+	testCases := map[string]struct {
+		stageNames []string
+		// checks are the stages seen by consecutive checks of a request as KYPO reports them
+		checks []check
+	}{
+		"allocation": {
+			stageNames: allocationStageNames,
+			checks: []check{
+				{stages: nil, expect: time.Second},
+				{stages: []string{"IN_QUEUE", "IN_QUEUE", "IN_QUEUE"}, expect: time.Minute},
+				{stages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"}, expect: time.Minute},
+				{stages: []string{"FINISHED", "RUNNING", "IN_QUEUE"}, expect: time.Second},
+				{stages: []string{"FINISHED", "FINISHED", "RUNNING"}, expect: time.Second},
+			},
+		},
+		"allocation-failed": {
+			stageNames: allocationStageNames,
+			checks: []check{
+				{stages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"}, expect: time.Minute},
+				{stages: []string{"FAILED", "IN_QUEUE", "IN_QUEUE"}, expect: time.Second},
+			},
+		},
+		"cleanup": {
+			stageNames: cleanupStageNames,
+			checks: []check{
+				{stages: nil, expect: time.Second},
+				{stages: []string{"IN_QUEUE", "IN_QUEUE", "IN_QUEUE"}, expect: time.Second},
+				{stages: []string{"RUNNING", "IN_QUEUE", "IN_QUEUE"}, expect: time.Second},
+				{stages: []string{"FINISHED", "RUNNING", "IN_QUEUE"}, expect: time.Second},
+				{stages: []string{"FINISHED", "FINISHED", "RUNNING"}, expect: time.Second},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for checks, check := range testCase.checks {
+				request := kypo.SandboxRequest{Stages: check.stages}
+				if got := poll.next(checks, request, testCase.stageNames); got != check.expect {
+					t.Errorf("check %d with stages %v: expected %s, got %s", checks, check.stages, check.expect, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPollStrategyNextJitter(t *testing.T) {
+	t.Parallel()
+
+	poll := pollStrategy{strategy: "exponential", min: time.Second, max: 10 * time.Second, multiplier: 2, jitter: 0.5}
+
+	for checks, interval := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second} {
+		lowest, highest := interval, interval
+		for i := 0; i < 1000; i++ {
+			got := poll.next(checks, kypo.SandboxRequest{}, allocationStageNames)
+			lowest, highest = min(lowest, got), max(highest, got)
+		}
+
+		if lowest < interval/2 || highest > interval*3/2 {
+			t.Errorf("check %d: expected times within %s and %s, got times within %s and %s", checks, interval/2, interval*3/2, lowest, highest)
+		}
+		if lowest == interval && highest == interval {
+			t.Errorf("check %d: expected times to be randomized, got always %s", checks, interval)
+		}
+	}
+}
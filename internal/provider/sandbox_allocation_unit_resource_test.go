@@ -51,6 +51,12 @@ data "kypo_sandbox_request_output" "test-terraform" {
 					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stages.0", "FINISHED"),
 					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stages.1", "FINISHED"),
 					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stages.2", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stage_details.%", "3"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stage_details.terraform.state", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stage_details.networking_ansible.state", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_request.stage_details.user_ansible.state", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_attempts", "1"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_unit.test", "allocation_failures.#", "0"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_unit.test", "created_by.id"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_unit.test", "created_by.sub"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_unit.test", "created_by.full_name"),
@@ -80,6 +86,8 @@ data "kypo_sandbox_request_output" "test-terraform" {
 				ResourceName:      "kypo_sandbox_allocation_unit.test",
 				ImportState:       true,
 				ImportStateVerify: true,
+				// The allocation attempts are only known to the Create operation
+				ImportStateVerifyIgnore: []string{"allocation_attempts", "allocation_failures"},
 			},
 			// Delete testing automatically occurs in TestCase
 		},
@@ -0,0 +1,510 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+
+	"terraform-provider-kypo/internal/validators"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &sandboxAllocationUnitsResource{}
+var _ resource.ResourceWithConfigure = &sandboxAllocationUnitsResource{}
+var _ resource.ResourceWithModifyPlan = &sandboxAllocationUnitsResource{}
+
+func NewSandboxAllocationUnitsResource() resource.Resource {
+	return &sandboxAllocationUnitsResource{}
+}
+
+// sandboxAllocationUnitsResource defines the resource implementation.
+type sandboxAllocationUnitsResource struct {
+	client *kypo.Client
+}
+
+type allocationUnitModel struct {
+	Id                  int64    `tfsdk:"id"`
+	AllocationRequestId int64    `tfsdk:"allocation_request_id"`
+	Stages              []string `tfsdk:"stages"`
+	Locked              bool     `tfsdk:"locked"`
+}
+
+func allocationUnitModels(allocationUnits []kypo.SandboxAllocationUnit) []allocationUnitModel {
+	models := make([]allocationUnitModel, 0, len(allocationUnits))
+	for _, allocationUnit := range allocationUnits {
+		models = append(models, allocationUnitModel{
+			Id:                  allocationUnit.Id,
+			AllocationRequestId: allocationUnit.AllocationRequest.Id,
+			Stages:              allocationUnit.AllocationRequest.Stages,
+			Locked:              allocationUnit.Locked,
+		})
+	}
+	return models
+}
+
+// healthyAllocationUnitCount returns the number of allocation units which did not fail. Locked allocation units
+// are always counted, because they cannot be replaced.
+func healthyAllocationUnitCount(allocationUnits []kypo.SandboxAllocationUnit) int64 {
+	var healthy int64
+	for _, allocationUnit := range allocationUnits {
+		if allocationUnit.Locked || !allocationRequestFailed(allocationUnit.AllocationRequest) {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// newAllocationUnitsId returns the id of a new resource in the given pool. The pool id alone is not unique,
+// because multiple resources may allocate in the same pool.
+func newAllocationUnitsId(poolId int64) (string, error) {
+	suffix := make([]byte, 8)
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%s", poolId, hex.EncodeToString(suffix)), nil
+}
+
+func (r *sandboxAllocationUnitsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sandbox_allocation_units"
+}
+
+func (r *sandboxAllocationUnitsResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Multiple sandbox allocation units in one sandbox pool, which are allocated by a single request and awaited together",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Id of the resource, which is `pool_id` followed by a random suffix, so that multiple resources can allocate in the same pool",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"pool_id": schema.Int64Attribute{
+				MarkdownDescription: "Id of the associated sandbox pool",
+				Required:            true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"unit_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of sandbox allocation units to allocate. Increasing the number allocates only the new allocation units, " +
+					"decreasing it cleans up the last allocation units. Failed allocation units are cleaned up and replaced by the next apply, see `healthy_count`. " +
+					"Named `unit_count`, because `count` is reserved by Terraform",
+				Required: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"healthy_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of allocation units in `units` whose allocation did not fail, locked allocation units are always counted. " +
+					"When it drops below `unit_count`, e.g. because an allocation unit failed or was cleaned up outside of Terraform, " +
+					"the plan updates the resource to clean up the failed allocation units and allocate the missing ones",
+				Computed: true,
+			},
+			"units": schema.ListNestedAttribute{
+				MarkdownDescription: "The allocated sandbox allocation units",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Id of the sandbox allocation unit",
+						},
+						"allocation_request_id": schema.Int64Attribute{
+							Computed:            true,
+							MarkdownDescription: "Id of the allocation request of the allocation unit",
+						},
+						"stages": schema.ListAttribute{
+							Computed:            true,
+							ElementType:         types.StringType,
+							MarkdownDescription: "Statuses of the allocation stages. List of three strings, where each is one of `IN_QUEUE`, `FINISHED`, `FAILED` or `RUNNING`",
+						},
+						"locked": schema.BoolAttribute{
+							Computed:            true,
+							MarkdownDescription: "Whether the allocation unit is locked. The allocation unit is locked when it is claimed by a Trainee and has an associated training run",
+						},
+					},
+				},
+			},
+			"prevent_destroy_when_locked": schema.BoolAttribute{
+				MarkdownDescription: "Whether destroying the resource fails while any of its allocation units is locked, defaults to `true`. " +
+					"A locked allocation unit is used by a training run and destroying it destroys the sandbox of the trainee. " +
+					"Must be applied before the destroy to take effect",
+				Optional: true,
+			},
+			"warning_on_allocation_failure": schema.BoolAttribute{
+				MarkdownDescription: "Whether to emit a warning instead of error when one of the allocation request stages fails",
+				Optional:            true,
+			},
+			"timeouts": timeouts.AttributesAll(ctx),
+			"poll_times": schema.SingleNestedAttribute{
+				MarkdownDescription: "Times after which the result of the operation is periodically checked. Times are strings which can be [parsed as a duration](https://pkg.go.dev/time#ParseDuration).",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"create": schema.StringAttribute{
+						MarkdownDescription: "Poll time for awaiting the allocation of the allocation units, defaults to `10s`. Is used by both `Create` and `Update` operations.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+					"delete": schema.StringAttribute{
+						MarkdownDescription: "Poll time for awaiting the cleanup of the allocation units, defaults to `5s`. Is used by both `Update` and `Delete` operations.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *sandboxAllocationUnitsResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*kypo.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected kypo.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+	r.client = client
+}
+
+// ModifyPlan plans an update when fewer healthy allocation units than `unit_count` exist,
+// which is detected by Read. `unit_count` itself stays as configured.
+func (r *sandboxAllocationUnitsResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to reconcile on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var unitCount, healthyCount types.Int64
+	var units types.List
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("unit_count"), &unitCount)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("units"), &units)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("healthy_count"), &healthyCount)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if unitCount.IsUnknown() || healthyCount.IsNull() || healthyCount.IsUnknown() {
+		return
+	}
+
+	if healthyCount.ValueInt64() < unitCount.ValueInt64() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("healthy_count"), types.Int64Unknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("units"), types.ListUnknown(units.ElementType(ctx)))...)
+	}
+}
+
+// readAllocationUnits reads the allocation units stored in the state and returns those which still exist.
+func (r *sandboxAllocationUnitsResource) readAllocationUnits(ctx context.Context, units []allocationUnitModel) ([]kypo.SandboxAllocationUnit, error) {
+	unitIds := make([]int64, 0, len(units))
+	for _, unit := range units {
+		unitIds = append(unitIds, unit.Id)
+	}
+
+	read, err := getAllocationUnits(ctx, r.client, unitIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocationUnits []kypo.SandboxAllocationUnit
+	for _, allocationUnit := range read {
+		if allocationUnit != nil {
+			allocationUnits = append(allocationUnits, *allocationUnit)
+		}
+	}
+	return allocationUnits, nil
+}
+
+// allocate creates `count` allocation units in the pool with a single request and waits until they are allocated.
+func (r *sandboxAllocationUnitsResource) allocate(ctx context.Context, diagnostics *diag.Diagnostics, poolId, count int64,
+	pollTime time.Duration, warningOnAllocationFailure bool) []kypo.SandboxAllocationUnit {
+	if count <= 0 {
+		return nil
+	}
+
+	allocationUnits, err := r.client.CreateSandboxAllocationUnits(ctx, poolId, count)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create sandbox allocation units, got error: %s", err))
+		return allocationUnits
+	}
+
+	err = awaitAllocationRequests(ctx, r.client, allocationUnits, pollTime)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation requests failed, got error: %s", err))
+		return allocationUnits
+	}
+
+	for i := range allocationUnits {
+		checkAllocationRequestResult(&allocationUnits[i], diagnostics, warningOnAllocationFailure, allocationUnits[i].Id)
+	}
+	return allocationUnits
+}
+
+func (r *sandboxAllocationUnitsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var poolId, unitCount types.Int64
+	var warningOnAllocationFailure, preventDestroyWhenLocked types.Bool
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("pool_id"), &poolId)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("unit_count"), &unitCount)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("warning_on_allocation_failure"), &warningOnAllocationFailure)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := newAllocationUnitsId(poolId.ValueInt64())
+	if err != nil {
+		resp.Diagnostics.AddError("Id Generation Error", fmt.Sprintf("Unable to generate id of sandbox allocation units, got error: %s", err))
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "create")
+	defer cancel()
+
+	pollTimeCreate := getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocationUnits := r.allocate(ctx, &resp.Diagnostics, poolId.ValueInt64(), unitCount.ValueInt64(),
+		pollTimeCreate, warningOnAllocationFailure.ValueBool())
+
+	// Allocation units which were created are saved even if the allocation failed, so that they are cleaned up later
+	if resp.Diagnostics.HasError() && len(allocationUnits) == 0 {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("pool_id"), poolId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("unit_count"), unitCount)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("units"), allocationUnitModels(allocationUnits))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_count"), healthyAllocationUnitCount(allocationUnits))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("warning_on_allocation_failure"), warningOnAllocationFailure)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("prevent_destroy_when_locked"), preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+
+	tflog.Trace(ctx, fmt.Sprintf("created %d sandbox allocation units in sandbox pool %d", len(allocationUnits), poolId.ValueInt64()))
+}
+
+func (r *sandboxAllocationUnitsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var units []allocationUnitModel
+	var timeoutsValue timeouts.Value
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("units"), &units)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "read")
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocationUnits, err := r.readAllocationUnits(ctx, units)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+
+	// Allocation units which are gone are removed and failed ones are not counted in `healthy_count`,
+	// so that the plan updates the resource when it is lower than `unit_count`
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("units"), allocationUnitModels(allocationUnits))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_count"), healthyAllocationUnitCount(allocationUnits))...)
+}
+
+func (r *sandboxAllocationUnitsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var poolId, unitCount types.Int64
+	var units []allocationUnitModel
+	var warningOnAllocationFailure, preventDestroyWhenLocked types.Bool
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("pool_id"), &poolId)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("units"), &units)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("unit_count"), &unitCount)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("warning_on_allocation_failure"), &warningOnAllocationFailure)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("warning_on_allocation_failure"), warningOnAllocationFailure)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("prevent_destroy_when_locked"), preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "update")
+	defer cancel()
+
+	pollTimeCreate := getPollTime(&resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second)
+	pollTimeDelete := getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocationUnits, err := r.readAllocationUnits(ctx, units)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+
+	// Failed allocation units are replaced, locked ones are kept because they are used by training runs
+	var failed []kypo.SandboxAllocationUnit
+	healthy := make([]kypo.SandboxAllocationUnit, 0, len(allocationUnits))
+	for _, allocationUnit := range allocationUnits {
+		if !allocationUnit.Locked && allocationRequestFailed(allocationUnit.AllocationRequest) {
+			resp.Diagnostics.AddWarning("Failed Sandbox Allocation Unit Cleaned Up",
+				fmt.Sprintf("Allocation of sandbox allocation unit %d has failed, the allocation unit is cleaned up and replaced", allocationUnit.Id))
+			failed = append(failed, allocationUnit)
+			continue
+		}
+		healthy = append(healthy, allocationUnit)
+	}
+	if len(failed) > 0 {
+		err = cleanupAllocationUnits(ctx, r.client, failed, pollTimeDelete)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox allocation units, got error: %s", err))
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("unit_count"), unitCount)...)
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("units"), allocationUnitModels(allocationUnits))...)
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_count"), healthyAllocationUnitCount(allocationUnits))...)
+			return
+		}
+		allocationUnits = healthy
+	}
+
+	difference := unitCount.ValueInt64() - int64(len(allocationUnits))
+	switch {
+	case difference > 0:
+		created := r.allocate(ctx, &resp.Diagnostics, poolId.ValueInt64(), difference, pollTimeCreate, warningOnAllocationFailure.ValueBool())
+		allocationUnits = append(allocationUnits, created...)
+	case difference < 0:
+		// The last unlocked allocation units are cleaned up
+		var kept, removed []kypo.SandboxAllocationUnit
+		for i := len(allocationUnits) - 1; i >= 0; i-- {
+			if int64(len(removed)) < -difference && !allocationUnits[i].Locked {
+				removed = append(removed, allocationUnits[i])
+			} else {
+				kept = append([]kypo.SandboxAllocationUnit{allocationUnits[i]}, kept...)
+			}
+		}
+		if int64(len(removed)) < -difference {
+			resp.Diagnostics.AddError("Locked Sandbox Allocation Units",
+				fmt.Sprintf("Only %d of %d sandbox allocation units can be cleaned up, the other sandbox allocation units are locked", len(removed), -difference))
+		}
+
+		err = cleanupAllocationUnits(ctx, r.client, removed, pollTimeDelete)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox allocation units, got error: %s", err))
+			kept = allocationUnits
+		}
+		allocationUnits = kept
+	}
+
+	// `unit_count` stays as configured even if the update failed, `healthy_count` tells the next plan what is missing
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("unit_count"), unitCount)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("units"), allocationUnitModels(allocationUnits))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_count"), healthyAllocationUnitCount(allocationUnits))...)
+}
+
+func (r *sandboxAllocationUnitsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var units []allocationUnitModel
+	var preventDestroyWhenLocked types.Bool
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("units"), &units)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("prevent_destroy_when_locked"), &preventDestroyWhenLocked)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "delete")
+	defer cancel()
+
+	pollTimeDelete := getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	allocationUnits, err := r.readAllocationUnits(ctx, units)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+
+	// No allocation unit is cleaned up when any of them is locked, like in the sandbox allocation unit resource
+	if preventDestroyWhenLocked.ValueBool() || preventDestroyWhenLocked.IsNull() {
+		var locked []int64
+		for _, allocationUnit := range allocationUnits {
+			if allocationUnit.Locked {
+				locked = append(locked, allocationUnit.Id)
+			}
+		}
+		if len(locked) > 0 {
+			resp.Diagnostics.AddError("Locked Sandbox Allocation Units",
+				fmt.Sprintf("Sandbox allocation units %v are locked, they are claimed by trainees and used by training runs. "+
+					"Destroying them would destroy the sandboxes of the trainees. Finish or delete the training runs, "+
+					"or apply `prevent_destroy_when_locked = false` first to destroy them anyway", locked))
+			return
+		}
+	}
+
+	err = cleanupAllocationUnits(ctx, r.client, allocationUnits, pollTimeDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete sandbox allocation units, got error: %s", err))
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+func TestSandboxAllocationUnitsResourceDelete(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		locked                   bool
+		preventDestroyWhenLocked any
+		expectError              bool
+	}{
+		"unlocked": {
+			preventDestroyWhenLocked: nil,
+		},
+		"locked-prevent-default": {
+			locked:                   true,
+			preventDestroyWhenLocked: nil,
+			expectError:              true,
+		},
+		"locked-prevent": {
+			locked:                   true,
+			preventDestroyWhenLocked: true,
+			expectError:              true,
+		},
+		"locked-not-prevented": {
+			locked:                   true,
+			preventDestroyWhenLocked: false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.units[1] = kypo.SandboxAllocationUnit{Id: 1, PoolId: 7}
+			fake.units[2] = kypo.SandboxAllocationUnit{Id: 2, PoolId: 7, Locked: testCase.locked}
+
+			r := &sandboxAllocationUnitsResource{client: client}
+			attributes := map[string]any{
+				"id":         "7-0123456789abcdef",
+				"pool_id":    int64(7),
+				"unit_count": int64(2),
+				"units":      []allocationUnitModel{{Id: 1, Stages: []string{}}, {Id: 2, Stages: []string{}}},
+				"poll_times": testPollTimes,
+			}
+			if testCase.preventDestroyWhenLocked != nil {
+				attributes["prevent_destroy_when_locked"] = testCase.preventDestroyWhenLocked
+			}
+			state := newTestState(t, r, attributes)
+			resp := resource.DeleteResponse{State: state}
+
+			r.Delete(ctx, resource.DeleteRequest{State: state}, &resp)
+
+			if resp.Diagnostics.HasError() != testCase.expectError {
+				t.Fatalf("expected error %t, got diagnostics: %v", testCase.expectError, resp.Diagnostics)
+			}
+			for _, request := range fake.requested() {
+				if testCase.expectError && strings.HasSuffix(request, "/cleanup-request") {
+					t.Errorf("expected no cleanup of a locked allocation unit, got request %s", request)
+				}
+			}
+			expectRemaining := 0
+			if testCase.expectError {
+				expectRemaining = 2
+			}
+			if remaining := len(fake.units); remaining != expectRemaining {
+				t.Errorf("expected %d remaining allocation units, got %d", expectRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestSandboxAllocationUnitsResourceReplaceFailed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	fake, client := newFakeKypo(t)
+	fake.allocationStages = []string{"FINISHED", "FINISHED", "FINISHED"}
+	fake.pools[7] = kypo.SandboxPool{Id: 7, MaxSize: 2, Size: 2}
+	fake.units[1] = kypo.SandboxAllocationUnit{Id: 1, PoolId: 7,
+		AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED"}}}
+	fake.units[2] = kypo.SandboxAllocationUnit{Id: 2, PoolId: 7,
+		AllocationRequest: kypo.SandboxRequest{Stages: []string{"FINISHED", "FAILED", "IN_QUEUE"}}}
+
+	r := &sandboxAllocationUnitsResource{client: client}
+	state := newTestState(t, r, map[string]any{
+		"id":            "7-0123456789abcdef",
+		"pool_id":       int64(7),
+		"unit_count":    int64(2),
+		"healthy_count": int64(2),
+		"units":         []allocationUnitModel{{Id: 1, Stages: []string{}}, {Id: 2, Stages: []string{}}},
+		"poll_times":    testPollTimes,
+	})
+
+	// Read counts the failed allocation unit as not healthy and keeps `unit_count`
+	readResp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read errors: %v", readResp.Diagnostics)
+	}
+	var unitCount, healthyCount types.Int64
+	readResp.State.GetAttribute(ctx, path.Root("unit_count"), &unitCount)
+	readResp.State.GetAttribute(ctx, path.Root("healthy_count"), &healthyCount)
+	if unitCount.ValueInt64() != 2 || healthyCount.ValueInt64() != 1 {
+		t.Fatalf("expected unit_count 2 and healthy_count 1, got %s and %s", unitCount, healthyCount)
+	}
+
+	// The plan updates the resource
+	state = readResp.State
+	plan := tfsdk.Plan(state)
+	planResp := resource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, resource.ModifyPlanRequest{State: state, Plan: plan}, &planResp)
+	planResp.Plan.GetAttribute(ctx, path.Root("healthy_count"), &healthyCount)
+	if planResp.Diagnostics.HasError() || !healthyCount.IsUnknown() {
+		t.Fatalf("expected unknown healthy_count, got %s and diagnostics: %v", healthyCount, planResp.Diagnostics)
+	}
+
+	// The update replaces the failed allocation unit
+	updateResp := resource.UpdateResponse{State: state}
+	r.Update(ctx, resource.UpdateRequest{State: state, Plan: planResp.Plan}, &updateResp)
+	if updateResp.Diagnostics.HasError() || updateResp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("expected a single warning, got diagnostics: %v", updateResp.Diagnostics)
+	}
+	if _, ok := fake.unit(2); ok {
+		t.Error("expected the failed allocation unit to be cleaned up")
+	}
+	var units []allocationUnitModel
+	updateResp.State.GetAttribute(ctx, path.Root("units"), &units)
+	updateResp.State.GetAttribute(ctx, path.Root("healthy_count"), &healthyCount)
+	if len(units) != 2 || units[0].Id != 1 || units[1].Id == 2 || healthyCount.ValueInt64() != 2 {
+		t.Errorf("expected allocation unit 1 and a new one with healthy_count 2, got %v and %s", units, healthyCount)
+	}
+}
+
+func TestNewAllocationUnitsId(t *testing.T) {
+	t.Parallel()
+
+	first, err := newAllocationUnitsId(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newAllocationUnitsId(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(first, "7-") || !strings.HasPrefix(second, "7-") {
+		t.Errorf("expected ids prefixed by the pool id, got %s and %s", first, second)
+	}
+	if first == second {
+		t.Errorf("expected unique ids, got %s twice", first)
+	}
+}
@@ -0,0 +1,73 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccSandboxAllocationUnitsResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders:        gitlabProvider,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size = 2
+}
+
+resource "kypo_sandbox_allocation_units" "test" {
+  pool_id    = kypo_sandbox_pool.test.id
+  unit_count = 2
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("kypo_sandbox_allocation_units.test", "pool_id",
+						"kypo_sandbox_pool.test", "id"),
+					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_units.test", "id"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "unit_count", "2"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "healthy_count", "2"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.#", "2"),
+					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_units.test", "units.0.id"),
+					resource.TestCheckResourceAttrSet("kypo_sandbox_allocation_units.test", "units.0.allocation_request_id"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.locked", "false"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.stages.#", "3"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.stages.0", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.stages.1", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.stages.2", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.1.stages.0", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.1.stages.1", "FINISHED"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.1.stages.2", "FINISHED"),
+				),
+			},
+			// Update and Read testing
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size = 2
+}
+
+resource "kypo_sandbox_allocation_units" "test" {
+  pool_id    = kypo_sandbox_pool.test.id
+  unit_count = 1
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "unit_count", "1"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "healthy_count", "1"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.#", "1"),
+					resource.TestCheckResourceAttr("kypo_sandbox_allocation_units.test", "units.0.stages.0", "FINISHED"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
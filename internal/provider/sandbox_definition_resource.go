@@ -12,9 +12,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+
+	"terraform-provider-kypo/internal/validators"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
@@ -53,11 +56,14 @@ func (r *sandboxDefinitionResource) Schema(_ context.Context, _ resource.SchemaR
 				Computed:            true,
 			},
 			"url": schema.StringAttribute{
-				MarkdownDescription: "Url to the Git repository of the sandbox definition",
+				MarkdownDescription: "Url to the Git repository of the sandbox definition. The repository is cloned by the KYPO instance using the Git credentials configured on the KYPO server, per-definition credentials are not supported by the KYPO API",
 				Required:            true,
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.String{
+					validators.GitUrl(),
+				},
 			},
 			"rev": schema.StringAttribute{
 				MarkdownDescription: "Revision of the Git repository of the sandbox definition",
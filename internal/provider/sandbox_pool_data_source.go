@@ -0,0 +1,245 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &sandboxPoolDataSource{}
+	_ datasource.DataSourceWithConfigure = &sandboxPoolDataSource{}
+)
+
+// NewSandboxPoolDataSource is a helper function to simplify the provider implementation.
+func NewSandboxPoolDataSource() datasource.DataSource {
+	return &sandboxPoolDataSource{}
+}
+
+// sandboxPoolDataSource is the data source implementation.
+type sandboxPoolDataSource struct {
+	client *kypo.Client
+}
+
+// sandboxPoolModel is kypo.SandboxPool with the hardware usage converted to numbers.
+type sandboxPoolModel struct {
+	Id            int64                  `tfsdk:"id"`
+	Size          int64                  `tfsdk:"size"`
+	MaxSize       int64                  `tfsdk:"max_size"`
+	LockId        int64                  `tfsdk:"lock_id"`
+	Rev           string                 `tfsdk:"rev"`
+	RevSha        string                 `tfsdk:"rev_sha"`
+	CreatedBy     kypo.User              `tfsdk:"created_by"`
+	HardwareUsage hardwareUsageModel     `tfsdk:"hardware_usage"`
+	Definition    kypo.SandboxDefinition `tfsdk:"definition"`
+}
+
+func newSandboxPoolModel(pool kypo.SandboxPool) sandboxPoolModel {
+	return sandboxPoolModel{
+		Id:            pool.Id,
+		Size:          pool.Size,
+		MaxSize:       pool.MaxSize,
+		LockId:        pool.LockId,
+		Rev:           pool.Rev,
+		RevSha:        pool.RevSha,
+		CreatedBy:     pool.CreatedBy,
+		HardwareUsage: parseHardwareUsage(pool.HardwareUsage),
+		Definition:    pool.Definition,
+	}
+}
+
+// Metadata returns the data source type name.
+func (r *sandboxPoolDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sandbox_pool"
+}
+
+// Schema defines the schema for the data source.
+func (r *sandboxPoolDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Sandbox pool, which can be managed outside of the current Terraform configuration",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.Int64Attribute{
+				Required:            true,
+				MarkdownDescription: "Id of the sandbox pool",
+			},
+			"size": schema.Int64Attribute{
+				MarkdownDescription: "Current number of allocated sandbox allocation units",
+				Computed:            true,
+			},
+			"max_size": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of allocated sandbox allocation units",
+				Computed:            true,
+			},
+			"lock_id": schema.Int64Attribute{
+				MarkdownDescription: "Id of the associated lock",
+				Computed:            true,
+			},
+			"rev": schema.StringAttribute{
+				MarkdownDescription: "Revision of the associated Git repository used for the sandbox pool",
+				Computed:            true,
+			},
+			"rev_sha": schema.StringAttribute{
+				MarkdownDescription: "Revision hash of the associated Git repository used for the sandbox pool",
+				Computed:            true,
+			},
+			"created_by": schema.SingleNestedAttribute{
+				MarkdownDescription: "Who created the sandbox pool",
+				Computed:            true,
+				Attributes: map[string]schema.Attribute{
+					"id": schema.Int64Attribute{
+						Computed:            true,
+						MarkdownDescription: "Id of the user",
+					},
+					"sub": schema.StringAttribute{
+						MarkdownDescription: "Sub of the user as given by an OIDC provider",
+						Computed:            true,
+					},
+					"full_name": schema.StringAttribute{
+						MarkdownDescription: "Full name of the user",
+						Computed:            true,
+					},
+					"given_name": schema.StringAttribute{
+						MarkdownDescription: "Given name of the user",
+						Computed:            true,
+					},
+					"family_name": schema.StringAttribute{
+						MarkdownDescription: "Family name of the user",
+						Computed:            true,
+					},
+					"mail": schema.StringAttribute{
+						MarkdownDescription: "Email of the user",
+						Computed:            true,
+					},
+				},
+			},
+			"hardware_usage": schema.SingleNestedAttribute{
+				MarkdownDescription: "Current resource usage by all allocation units in the pool. Values which KYPO does not report as a number are null",
+				Computed:            true,
+				Attributes: map[string]schema.Attribute{
+					"vcpu": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used vCPUs relative to the cloud quota",
+						Computed:            true,
+					},
+					"ram": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used RAM relative to the cloud quota",
+						Computed:            true,
+					},
+					"instances": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used instances relative to the cloud quota",
+						Computed:            true,
+					},
+					"network": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used networks relative to the cloud quota",
+						Computed:            true,
+					},
+					"subnet": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used subnets relative to the cloud quota",
+						Computed:            true,
+					},
+					"port": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used ports relative to the cloud quota",
+						Computed:            true,
+					},
+				},
+			},
+			"definition": schema.SingleNestedAttribute{
+				MarkdownDescription: "The associated sandbox definition",
+				Computed:            true,
+				Attributes: map[string]schema.Attribute{
+					"id": schema.Int64Attribute{
+						MarkdownDescription: "Id of the associated sandbox definition",
+						Computed:            true,
+					},
+					"name": schema.StringAttribute{
+						MarkdownDescription: "Name of the sandbox definition",
+						Computed:            true,
+					},
+					"url": schema.StringAttribute{
+						MarkdownDescription: "Url to the Git repository of the sandbox definition",
+						Computed:            true,
+					},
+					"rev": schema.StringAttribute{
+						MarkdownDescription: "Revision of the Git repository of the sandbox definition",
+						Computed:            true,
+					},
+					"created_by": schema.SingleNestedAttribute{
+						MarkdownDescription: "Who created the sandbox definition",
+						Computed:            true,
+						Attributes: map[string]schema.Attribute{
+							"id": schema.Int64Attribute{
+								Computed:            true,
+								MarkdownDescription: "Id of the user",
+							},
+							"sub": schema.StringAttribute{
+								MarkdownDescription: "Sub of the user as given by an OIDC provider",
+								Computed:            true,
+							},
+							"full_name": schema.StringAttribute{
+								MarkdownDescription: "Full name of the user",
+								Computed:            true,
+							},
+							"given_name": schema.StringAttribute{
+								MarkdownDescription: "Given name of the user",
+								Computed:            true,
+							},
+							"family_name": schema.StringAttribute{
+								MarkdownDescription: "Family name of the user",
+								Computed:            true,
+							},
+							"mail": schema.StringAttribute{
+								MarkdownDescription: "Email of the user",
+								Computed:            true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *sandboxPoolDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*kypo.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected kypo.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+	r.client = client
+}
+
+func (r *sandboxPoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var id types.Int64
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("id"), &id)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, err := r.client.GetSandboxPool(ctx, id.ValueInt64())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox pool, got error: %s", err))
+		return
+	}
+
+	poolModel := newSandboxPoolModel(*pool)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &poolModel)...)
+}
@@ -0,0 +1,54 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccSandboxPoolDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders:        gitlabProvider,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size = 2
+}
+
+data "kypo_sandbox_pool" "test" {
+  id = kypo_sandbox_pool.test.id
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "id",
+						"kypo_sandbox_pool.test", "id"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "max_size",
+						"kypo_sandbox_pool.test", "max_size"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "rev",
+						"kypo_sandbox_pool.test", "rev"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "rev_sha",
+						"kypo_sandbox_pool.test", "rev_sha"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "definition.id",
+						"kypo_sandbox_pool.test", "definition.id"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "definition.url",
+						"kypo_sandbox_pool.test", "definition.url"),
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool.test", "created_by.id",
+						"kypo_sandbox_pool.test", "created_by.id"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "size"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.vcpu"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.ram"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.instances"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.network"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.subnet"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool.test", "hardware_usage.port"),
+				),
+			},
+		},
+	})
+}
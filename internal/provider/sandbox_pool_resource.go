@@ -5,21 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+
+	"terraform-provider-kypo/internal/plan_modifiers"
+	"terraform-provider-kypo/internal/validators"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &sandboxPoolResource{}
 var _ resource.ResourceWithImportState = &sandboxPoolResource{}
 var _ resource.ResourceWithConfigure = &sandboxPoolResource{}
+var _ resource.ResourceWithValidateConfig = &sandboxPoolResource{}
+var _ resource.ResourceWithModifyPlan = &sandboxPoolResource{}
 
 func NewSandboxPoolResource() resource.Resource {
 	return &sandboxPoolResource{}
@@ -30,11 +42,45 @@ type sandboxPoolResource struct {
 	client *kypo.Client
 }
 
+// sandboxPoolResourceModel holds the attributes of kypo.SandboxPool which are stored by the pool resource.
+// The size and hardware usage of the pool change with every allocation and are read by `kypo_sandbox_pool_usage` instead.
+type sandboxPoolResourceModel struct {
+	Id         int64                  `tfsdk:"id"`
+	MaxSize    int64                  `tfsdk:"max_size"`
+	LockId     int64                  `tfsdk:"lock_id"`
+	Rev        string                 `tfsdk:"rev"`
+	RevSha     string                 `tfsdk:"rev_sha"`
+	CreatedBy  kypo.User              `tfsdk:"created_by"`
+	Definition kypo.SandboxDefinition `tfsdk:"definition"`
+}
+
+func setSandboxPoolState(ctx context.Context, pool kypo.SandboxPool, resp response) {
+	setState(ctx, sandboxPoolResourceModel{
+		Id:         pool.Id,
+		MaxSize:    pool.MaxSize,
+		LockId:     pool.LockId,
+		Rev:        pool.Rev,
+		RevSha:     pool.RevSha,
+		CreatedBy:  pool.CreatedBy,
+		Definition: pool.Definition,
+	}, resp)
+
+	// The deprecated `size` and `hardware_usage` are only saved when the pool is created or imported. They are not
+	// refreshed afterwards, so that allocations in the pool do not show up as changes of the pool.
+	var size types.Int64
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("size"), &size)...)
+	if !size.IsNull() && !size.IsUnknown() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("size"), pool.Size)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("hardware_usage"), pool.HardwareUsage)...)
+}
+
 func (r *sandboxPoolResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_sandbox_pool"
 }
 
-func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+func (r *sandboxPoolResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "Sandbox pool",
@@ -48,8 +94,13 @@ func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest
 				},
 			},
 			"size": schema.Int64Attribute{
-				MarkdownDescription: "Current number of allocated sandbox allocation units",
-				Computed:            true,
+				MarkdownDescription: "Number of allocated sandbox allocation units when the sandbox pool was created or imported, it is not refreshed. " +
+					"Deprecated, use `size` of the `kypo_sandbox_pool_usage` data source instead",
+				DeprecationMessage: "Use the size attribute of the kypo_sandbox_pool_usage data source instead. This attribute will be removed in the next major version.",
+				Computed:           true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"max_size": schema.Int64Attribute{
 				MarkdownDescription: "Maximum number of allocated sandbox allocation units",
@@ -58,6 +109,27 @@ func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest
 					int64planmodifier.RequiresReplace(),
 				},
 			},
+			"desired_size": schema.Int64Attribute{
+				MarkdownDescription: "Number of healthy, unlocked sandbox allocation units the pool should hold. " +
+					"When set, `Create` and `Update` allocate or clean up allocation units managed by this resource until the number is reached. " +
+					"Locked allocation units are never cleaned up and do not count towards this number, they still take up slots of `max_size`, so fewer allocation units may be allocated. " +
+					"Failed allocations are reported as warnings and replaced by the next apply. Must not be greater than `max_size`. " +
+					"Removing `desired_size` keeps the allocation units in `allocation_unit_ids` in the pool until it is destroyed, set it to `0` to clean them up",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"healthy_size": schema.Int64Attribute{
+				MarkdownDescription: "Number of healthy, unlocked sandbox allocation units in `allocation_unit_ids`. " +
+					"When it drops below `desired_size`, e.g. because an allocation unit failed, got locked or was cleaned up, the plan updates the pool to allocate the missing allocation units",
+				Computed: true,
+			},
+			"allocation_unit_ids": schema.ListAttribute{
+				MarkdownDescription: "Ids of the sandbox allocation units allocated in the pool because of `desired_size`",
+				Computed:            true,
+				ElementType:         types.Int64Type,
+			},
 			"lock_id": schema.Int64Attribute{
 				MarkdownDescription: "Id of the associated lock",
 				Computed:            true,
@@ -101,8 +173,13 @@ func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest
 				},
 			},
 			"hardware_usage": schema.SingleNestedAttribute{
-				MarkdownDescription: "Current resource usage by all allocation units in the pool",
-				Computed:            true,
+				MarkdownDescription: "Resource usage by all allocation units in the pool when the sandbox pool was created or imported, it is not refreshed. " +
+					"Deprecated, use `hardware_usage` of the `kypo_sandbox_pool_usage` data source instead",
+				DeprecationMessage: "Use the hardware_usage attribute of the kypo_sandbox_pool_usage data source instead. This attribute will be removed in the next major version.",
+				Computed:           true,
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.UseStateForUnknown(),
+				},
 				Attributes: map[string]schema.Attribute{
 					"vcpu": schema.StringAttribute{
 						MarkdownDescription: "The percentage of used vCPUs relative to the cloud quota",
@@ -135,10 +212,11 @@ func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest
 				Required:            true,
 				Attributes: map[string]schema.Attribute{
 					"id": schema.Int64Attribute{
-						MarkdownDescription: "Id of the associated sandbox definition",
+						MarkdownDescription: "Id of the associated sandbox definition. Changing the id, or deleting the sandbox definition, replaces the sandbox pool",
 						Required:            true,
 						PlanModifiers: []planmodifier.Int64{
 							int64planmodifier.RequiresReplace(),
+							plan_modifiers.DeletedDefinitionPlanModifier{},
 						},
 					},
 					"name": schema.StringAttribute{
@@ -185,6 +263,32 @@ func (r *sandboxPoolResource) Schema(_ context.Context, _ resource.SchemaRequest
 					},
 				},
 			},
+			"force_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Whether to clean up all allocation units in the pool, including locked ones and ones not managed by this resource, before the pool is deleted. " +
+					"Running allocation requests of allocation units managed by `desired_size` are cancelled first. The `delete` timeout applies to the whole cleanup",
+				Optional: true,
+			},
+			"timeouts": timeouts.AttributesAll(ctx),
+			"poll_times": schema.SingleNestedAttribute{
+				MarkdownDescription: "Times after which the result of allocations and cleanups of allocation units managed by `desired_size` is periodically checked. Times are strings which can be [parsed as a duration](https://pkg.go.dev/time#ParseDuration).",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"create": schema.StringAttribute{
+						MarkdownDescription: "Poll time for awaiting the allocation of allocation units, defaults to `10s`.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+					"delete": schema.StringAttribute{
+						MarkdownDescription: "Poll time for awaiting the cleanup of allocation units, defaults to `5s`.",
+						Optional:            true,
+						Validators: []validator.String{
+							validators.TimeDuration(),
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -208,12 +312,344 @@ func (r *sandboxPoolResource) Configure(_ context.Context, req resource.Configur
 	r.client = client
 }
 
+// ModifyPlan plans an update of the pool when fewer healthy allocation units than `desired_size` exist,
+// which is detected by Read. `desired_size` itself stays as configured.
+func (r *sandboxPoolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to reconcile on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var desiredSize, stateDesiredSize, healthySize types.Int64
+	var unitIds []int64
+
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("desired_size"), &desiredSize)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("desired_size"), &stateDesiredSize)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("healthy_size"), &healthySize)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_unit_ids"), &unitIds)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if desiredSize.IsNull() && !stateDesiredSize.IsNull() && len(unitIds) > 0 {
+		resp.Diagnostics.AddAttributeWarning(path.Root("desired_size"), "Sandbox Allocation Units Kept",
+			fmt.Sprintf("desired_size is removed, the %d sandbox allocation units allocated because of it are kept in the pool "+
+				"until the pool is destroyed. Set desired_size to 0 first to clean them up", len(unitIds)))
+	}
+
+	if desiredSize.IsNull() || desiredSize.IsUnknown() || healthySize.IsNull() || healthySize.IsUnknown() {
+		return
+	}
+
+	if healthySize.ValueInt64() < desiredSize.ValueInt64() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("healthy_size"), types.Int64Unknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("allocation_unit_ids"), types.ListUnknown(types.Int64Type))...)
+	}
+}
+
+func (r *sandboxPoolResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var maxSize, desiredSize types.Int64
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("max_size"), &maxSize)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("desired_size"), &desiredSize)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if maxSize.IsNull() || maxSize.IsUnknown() || desiredSize.IsNull() || desiredSize.IsUnknown() {
+		return
+	}
+
+	if desiredSize.ValueInt64() > maxSize.ValueInt64() {
+		resp.Diagnostics.AddAttributeError(path.Root("desired_size"), "Invalid Attribute Value",
+			fmt.Sprintf("desired_size %d must not be greater than max_size %d", desiredSize.ValueInt64(), maxSize.ValueInt64()))
+	}
+}
+
+// readManagedAllocationUnits reads the allocation units managed by `desired_size`. Returns the ids of those which
+// still exist and the number of those which are healthy and unlocked.
+func (r *sandboxPoolResource) readManagedAllocationUnits(ctx context.Context, unitIds []int64) ([]int64, int64, error) {
+	read, err := getAllocationUnits(ctx, r.client, unitIds)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	existingUnitIds := []int64{}
+	var healthy int64
+	for _, allocationUnit := range read {
+		if allocationUnit == nil {
+			continue
+		}
+		existingUnitIds = append(existingUnitIds, allocationUnit.Id)
+		if !allocationUnit.Locked && !allocationRequestFailed(allocationUnit.AllocationRequest) {
+			healthy++
+		}
+	}
+	return existingUnitIds, healthy, nil
+}
+
+func allocationUnitIds(allocationUnits ...[]kypo.SandboxAllocationUnit) []int64 {
+	var unitIds []int64
+	for _, units := range allocationUnits {
+		for _, allocationUnit := range units {
+			unitIds = append(unitIds, allocationUnit.Id)
+		}
+	}
+	return unitIds
+}
+
+// reconcileAllocationUnits allocates or cleans up the allocation units of the pool, which were allocated because
+// of `desired_size`, until `desiredSize` healthy unlocked allocation units exist. Locked allocation units are kept.
+// Running allocation requests are awaited together before the allocation units are counted.
+// Returns the ids of the allocation units which are managed by the pool after the reconciliation.
+func (r *sandboxPoolResource) reconcileAllocationUnits(ctx context.Context, diagnostics *diag.Diagnostics, poolId, desiredSize int64,
+	unitIds []int64, pollTimeCreate, pollTimeDelete time.Duration) []int64 {
+	read, err := getAllocationUnits(ctx, r.client, unitIds)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return unitIds
+	}
+
+	var allocationUnits, running []kypo.SandboxAllocationUnit
+	for _, allocationUnit := range read {
+		if allocationUnit == nil {
+			continue
+		}
+		if allocationRequestRunning(allocationUnit.AllocationRequest) {
+			running = append(running, *allocationUnit)
+			continue
+		}
+		allocationUnits = append(allocationUnits, *allocationUnit)
+	}
+
+	if len(running) > 0 {
+		tflog.Info(ctx, fmt.Sprintf("sandbox pool %d: awaiting allocation of %d sandbox allocation units", poolId, len(running)))
+		err = awaitAllocationRequests(ctx, r.client, running, pollTimeCreate)
+		if err != nil {
+			diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation requests of sandbox allocation units failed, got error: %s", err))
+			return unitIds
+		}
+		allocationUnits = append(allocationUnits, running...)
+	}
+
+	var locked, healthy, failed []kypo.SandboxAllocationUnit
+	for _, allocationUnit := range allocationUnits {
+		switch {
+		case allocationUnit.Locked:
+			locked = append(locked, allocationUnit)
+		case allocationRequestFailed(allocationUnit.AllocationRequest):
+			failed = append(failed, allocationUnit)
+		default:
+			healthy = append(healthy, allocationUnit)
+		}
+	}
+
+	toCleanup := failed
+	for _, allocationUnit := range failed {
+		diagnostics.AddWarning("Failed Sandbox Allocation Unit Cleaned Up",
+			fmt.Sprintf("Allocation of sandbox allocation unit %d in sandbox pool %d has failed, the allocation unit is cleaned up", allocationUnit.Id, poolId))
+	}
+	if excess := int64(len(healthy)) - desiredSize; excess > 0 {
+		toCleanup = append(toCleanup, healthy[len(healthy)-int(excess):]...)
+		healthy = healthy[:len(healthy)-int(excess)]
+	}
+
+	if len(toCleanup) > 0 {
+		tflog.Info(ctx, fmt.Sprintf("sandbox pool %d: cleaning up %d sandbox allocation units", poolId, len(toCleanup)))
+		err = cleanupAllocationUnits(ctx, r.client, toCleanup, pollTimeDelete)
+		if err != nil {
+			diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox allocation units, got error: %s", err))
+			return allocationUnitIds(locked, healthy, toCleanup)
+		}
+	}
+
+	managed := allocationUnitIds(locked, healthy)
+	missing := desiredSize - int64(len(healthy))
+	if missing <= 0 {
+		return managed
+	}
+
+	// Locked allocation units and ones not managed by the pool also take up slots, so only as many allocation units
+	// as fit into the pool are allocated
+	pool, err := r.client.GetSandboxPool(ctx, poolId)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox pool, got error: %s", err))
+		return managed
+	}
+	if free := max(pool.MaxSize-pool.Size, 0); missing > free {
+		diagnostics.AddWarning("Sandbox Pool Full",
+			fmt.Sprintf("Sandbox pool %d holds %d of %d sandbox allocation units, only %d of the %d missing sandbox allocation units are allocated",
+				poolId, pool.Size, pool.MaxSize, free, missing))
+		missing = free
+	}
+	if missing == 0 {
+		return managed
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("sandbox pool %d: allocating %d sandbox allocation units", poolId, missing))
+	created, err := r.client.CreateSandboxAllocationUnits(ctx, poolId, missing)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create sandbox allocation units, got error: %s", err))
+		return managed
+	}
+	managed = append(managed, allocationUnitIds(created)...)
+
+	err = awaitAllocationRequests(ctx, r.client, created, pollTimeCreate)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("awaiting allocation requests of sandbox allocation units failed, got error: %s", err))
+		return managed
+	}
+
+	// Failed allocation units are only reported as warnings, so that the pool is not tainted. They stay managed
+	// by the pool and are not counted in `healthy_size`, so that the next plan cleans them up and replaces them.
+	for i := range created {
+		checkAllocationRequestResult(&created[i], diagnostics, true, created[i].Id)
+	}
+	return managed
+}
+
+// awaitSandboxPoolEmpty waits until the pool holds no allocation units. The check is done once every `pollTime` elapses.
+func (r *sandboxPoolResource) awaitSandboxPoolEmpty(ctx context.Context, poolId int64, pollTime time.Duration) error {
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			pool, err := r.client.GetSandboxPool(ctx, poolId)
+			if err != nil {
+				return err
+			}
+			if pool.Size == 0 {
+				return nil
+			}
+			tflog.Info(ctx, fmt.Sprintf("sandbox pool %d: awaiting cleanup of %d sandbox allocation units", poolId, pool.Size))
+		}
+	}
+}
+
+// forceCleanupSandboxPool cancels running allocation requests of the given allocation units, starts the cleanup
+// of all allocation units in the pool and waits until the pool is empty. The cleanup requests of the given
+// allocation units are awaited together, so that a failed cleanup is reported before the pool is checked.
+func (r *sandboxPoolResource) forceCleanupSandboxPool(ctx context.Context, diagnostics *diag.Diagnostics, poolId int64, unitIds []int64, pollTime time.Duration) {
+	read, err := getAllocationUnits(ctx, r.client, unitIds)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+	var existing []int64
+	for _, allocationUnit := range read {
+		if allocationUnit == nil {
+			continue
+		}
+		if allocationRequestRunning(allocationUnit.AllocationRequest) {
+			err = r.client.CancelSandboxAllocationRequest(ctx, allocationUnit.AllocationRequest.Id)
+			if err != nil {
+				diagnostics.AddError("Client Error", fmt.Sprintf("Unable to cancel allocation request of sandbox allocation unit %d, got error: %s", allocationUnit.Id, err))
+				return
+			}
+		}
+		existing = append(existing, allocationUnit.Id)
+	}
+
+	pool, err := r.client.GetSandboxPool(ctx, poolId)
+	if errors.Is(err, kypo.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox pool, got error: %s", err))
+		return
+	}
+	if pool.Size == 0 {
+		return
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("sandbox pool %d: cleaning up all %d sandbox allocation units", poolId, pool.Size))
+	err = r.client.CleanupSandboxPool(ctx, poolId, true)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox pool, got error: %s", err))
+		return
+	}
+
+	err = awaitCleanupRequests(ctx, r.client, existing, pollTime)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("awaiting cleanup requests of sandbox allocation units failed, got error: %s", err))
+		return
+	}
+
+	err = r.awaitSandboxPoolEmpty(ctx, poolId, pollTime)
+	if err != nil {
+		diagnostics.AddError("Client Error", fmt.Sprintf("awaiting cleanup of sandbox pool failed, got error: %s", err))
+	}
+}
+
+// applyDesiredSize reconciles the allocation units of the pool with `desired_size` from the plan
+// and saves the resulting pool into the state.
+func (r *sandboxPoolResource) applyDesiredSize(ctx context.Context, plan tfsdk.Plan, resp response, poolId int64, unitIds []int64) {
+	var desiredSize types.Int64
+	var forceDestroy types.Bool
+	var pollTimes types.Object
+
+	resp.Diagnostics.Append(plan.GetAttribute(ctx, path.Root("desired_size"), &desiredSize)...)
+	resp.Diagnostics.Append(plan.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("force_destroy"), forceDestroy)...)
+	resp.Diagnostics.Append(plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pollTimeCreate := getPollTime(resp.Diagnostics, ctx, pollTimes, "create", 10*time.Second)
+	pollTimeDelete := getPollTime(resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !desiredSize.IsNull() {
+		unitIds = r.reconcileAllocationUnits(ctx, resp.Diagnostics, poolId, desiredSize.ValueInt64(), unitIds, pollTimeCreate, pollTimeDelete)
+	}
+	if unitIds == nil {
+		unitIds = []int64{}
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_unit_ids"), unitIds)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("desired_size"), desiredSize)...)
+
+	_, healthy, err := r.readManagedAllocationUnits(ctx, unitIds)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_size"), healthy)...)
+
+	pool, err := r.client.GetSandboxPool(ctx, poolId)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox pool, got error: %s", err))
+		return
+	}
+
+	setSandboxPoolState(ctx, *pool, resp)
+}
+
 func (r *sandboxPoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var definitionId, maxSize types.Int64
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
 
 	// Read Terraform plan data into the model
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("definition").AtName("id"), &definitionId)...)
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("max_size"), &maxSize)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "create")
+	defer cancel()
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -232,13 +668,32 @@ func (r *sandboxPoolResource) Create(ctx context.Context, req resource.CreateReq
 	tflog.Trace(ctx, fmt.Sprintf("created sandbox pool %d", pool.Id))
 
 	// Save data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &pool)...)
+	setSandboxPoolState(ctx, *pool, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.applyDesiredSize(ctx, req.Plan, response{State: &resp.State, Diagnostics: &resp.Diagnostics}, pool.Id, nil)
 }
 
 func (r *sandboxPoolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	var id types.Int64
+	var id, definitionId types.Int64
+	var unitIds []int64
+	var timeoutsValue timeouts.Value
 
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("definition").AtName("id"), &definitionId)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_unit_ids"), &unitIds)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "read")
+	defer cancel()
 
 	if resp.Diagnostics.HasError() {
 		return
@@ -257,25 +712,128 @@ func (r *sandboxPoolResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	// Units which are gone are no longer managed. Units which are not healthy or got locked are not counted
+	// in `healthy_size`, so that the plan updates the pool when it is lower than `desired_size`.
+	existingUnitIds, healthy, err := r.readManagedAllocationUnits(ctx, unitIds)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("allocation_unit_ids"), existingUnitIds)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("healthy_size"), healthy)...)
+
+	// A pool whose sandbox definition was deleted may no longer embed it, the configured id is kept then
+	if pool.Definition.Id == 0 {
+		pool.Definition.Id = definitionId.ValueInt64()
+	}
+
 	// Save updated data into Terraform state
-	resp.Diagnostics.Append(resp.State.Set(ctx, &pool)...)
+	setSandboxPoolState(ctx, *pool, response{State: &resp.State, Diagnostics: &resp.Diagnostics})
+
+	// The pool keeps referencing its sandbox definition after the definition is deleted, e.g. because it was replaced,
+	// so the definition is read to find out whether it still exists. The plan then replaces the pool, see
+	// plan_modifiers.DeletedDefinitionPlanModifier.
+	_, err = r.client.GetSandboxDefinition(ctx, pool.Definition.Id)
+	if errors.Is(err, kypo.ErrNotFound) {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, plan_modifiers.DeletedDefinitionPrivateKey, []byte("true"))...)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox definition of sandbox pool, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, plan_modifiers.DeletedDefinitionPrivateKey, nil)...)
 }
 
-func (r *sandboxPoolResource) Update(_ context.Context, _ resource.UpdateRequest, _ *resource.UpdateResponse) {
+func (r *sandboxPoolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var id types.Int64
+	var unitIds []int64
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
+
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_unit_ids"), &unitIds)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("timeouts"), timeoutsValue)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("poll_times"), pollTimes)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "update")
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.applyDesiredSize(ctx, req.Plan, response{State: &resp.State, Diagnostics: &resp.Diagnostics}, id.ValueInt64(), unitIds)
 }
 
 func (r *sandboxPoolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var id types.Int64
+	var unitIds []int64
+	var forceDestroy types.Bool
+	var timeoutsValue timeouts.Value
+	var pollTimes types.Object
 
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("allocation_unit_ids"), &unitIds)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("force_destroy"), &forceDestroy)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("timeouts"), &timeoutsValue)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("poll_times"), &pollTimes)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	ctx, cancel := setTimeout(&resp.Diagnostics, ctx, timeoutsValue, "delete")
+	defer cancel()
+
+	pollTimeDelete := getPollTime(&resp.Diagnostics, ctx, pollTimes, "delete", 5*time.Second)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if forceDestroy.ValueBool() {
+		r.forceCleanupSandboxPool(ctx, &resp.Diagnostics, id.ValueInt64(), unitIds, pollTimeDelete)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		unitIds = nil
+	}
+
+	// Allocation units allocated because of `desired_size` are cleaned up, locked ones are kept
+	// and make the deletion of the pool fail.
+	read, err := getAllocationUnits(ctx, r.client, unitIds)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox allocation units, got error: %s", err))
+		return
+	}
+	var unlocked []kypo.SandboxAllocationUnit
+	for _, allocationUnit := range read {
+		if allocationUnit == nil {
+			continue
+		}
+		if allocationUnit.Locked {
+			resp.Diagnostics.AddWarning("Locked Sandbox Allocation Unit Kept",
+				fmt.Sprintf("Sandbox allocation unit %d is locked and will not be cleaned up", allocationUnit.Id))
+			continue
+		}
+		unlocked = append(unlocked, *allocationUnit)
+	}
+	err = cleanupAllocationUnits(ctx, r.client, unlocked, pollTimeDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to clean up sandbox allocation units, got error: %s", err))
+		return
+	}
+
 	// If applicable, this is a great opportunity to initialize any necessary
 	// provider client data and make a call using it.
-	err := r.client.DeleteSandboxPool(ctx, id.ValueInt64())
+	err = r.client.DeleteSandboxPool(ctx, id.ValueInt64())
 	if errors.Is(err, kypo.ErrNotFound) {
 		return
 	}
@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+	"golang.org/x/exp/slices"
+
+	"terraform-provider-kypo/internal/plan_modifiers"
+)
+
+func TestSandboxPoolResourceReadDefinition(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		poolDefinition   kypo.SandboxDefinition
+		definitionExists bool
+		priorDeleted     bool
+		expectDeleted    bool
+	}{
+		"definition-exists": {
+			poolDefinition:   kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"},
+			definitionExists: true,
+		},
+		"definition-exists-again": {
+			poolDefinition:   kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"},
+			definitionExists: true,
+			priorDeleted:     true,
+		},
+		"definition-deleted": {
+			poolDefinition: kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"},
+			expectDeleted:  true,
+		},
+		"definition-deleted-not-embedded": {
+			expectDeleted: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.pools[1] = kypo.SandboxPool{Id: 1, MaxSize: 2, Definition: testCase.poolDefinition}
+			if testCase.definitionExists {
+				fake.definitions[3] = kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"}
+			}
+
+			r := &sandboxPoolResource{client: client}
+			state := newTestState(t, r, map[string]any{
+				"id":                  int64(1),
+				"allocation_unit_ids": []int64{},
+				"definition": kypo.SandboxDefinition{
+					Id: 3, Url: "https://gitlab.example/definition.git",
+				},
+			})
+			resp := resource.ReadResponse{State: state}
+			resp.Private = newTestPrivate(resp.Private)
+			if testCase.priorDeleted {
+				resp.Private.SetKey(ctx, plan_modifiers.DeletedDefinitionPrivateKey, []byte("true"))
+			}
+
+			r.Read(ctx, resource.ReadRequest{State: state, Private: resp.Private}, &resp)
+
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() > 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if !slices.Contains(fake.requested(), "GET /definitions/3") {
+				t.Errorf("expected the sandbox definition to be read, got requests: %v", fake.requested())
+			}
+			deleted, _ := resp.Private.GetKey(ctx, plan_modifiers.DeletedDefinitionPrivateKey)
+			if (deleted != nil) != testCase.expectDeleted {
+				t.Errorf("expected the sandbox definition to be recorded as deleted %t, got %q", testCase.expectDeleted, deleted)
+			}
+
+			// The configured id stays in the state, the plan modifier replaces the pool instead
+			var definitionId types.Int64
+			resp.State.GetAttribute(ctx, path.Root("definition").AtName("id"), &definitionId)
+			if !definitionId.Equal(types.Int64Value(3)) {
+				t.Errorf("expected definition id 3, got %s", definitionId)
+			}
+		})
+	}
+}
+
+func TestSandboxPoolResourceDesiredSize(t *testing.T) {
+	t.Parallel()
+
+	finished := kypo.SandboxRequest{Stages: []string{"FINISHED", "FINISHED", "FINISHED"}}
+
+	testCases := map[string]struct {
+		pool              *kypo.SandboxPool
+		units             []kypo.SandboxAllocationUnit
+		failedAllocations int
+		// stateDesiredSize is nil when the pool is created
+		stateDesiredSize   any
+		desiredSize        int64
+		expectWarnings     int
+		expectHealthySize  int64
+		expectUnitCount    int
+		expectKeptUnitIds  []int64
+		expectPoolUnitSize int64
+	}{
+		"create-with-failed-unit": {
+			failedAllocations:  1,
+			desiredSize:        2,
+			expectWarnings:     1,
+			expectHealthySize:  1,
+			expectUnitCount:    2,
+			expectPoolUnitSize: 2,
+		},
+		"lower-size-with-locked-unit": {
+			pool: &kypo.SandboxPool{Id: 7, Size: 3, MaxSize: 3},
+			units: []kypo.SandboxAllocationUnit{
+				{Id: 1, PoolId: 7, Locked: true, AllocationRequest: finished},
+				{Id: 2, PoolId: 7, AllocationRequest: finished},
+				{Id: 3, PoolId: 7, AllocationRequest: finished},
+			},
+			stateDesiredSize:   int64(2),
+			desiredSize:        1,
+			expectHealthySize:  1,
+			expectUnitCount:    2,
+			expectKeptUnitIds:  []int64{1, 2},
+			expectPoolUnitSize: 2,
+		},
+		"grow-past-max-size": {
+			pool: &kypo.SandboxPool{Id: 7, Size: 2, MaxSize: 3},
+			units: []kypo.SandboxAllocationUnit{
+				{Id: 1, PoolId: 7, Locked: true, AllocationRequest: finished},
+				{Id: 2, PoolId: 7, AllocationRequest: finished},
+			},
+			stateDesiredSize:   int64(1),
+			desiredSize:        3,
+			expectWarnings:     1,
+			expectHealthySize:  2,
+			expectUnitCount:    3,
+			expectKeptUnitIds:  []int64{1, 2},
+			expectPoolUnitSize: 3,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.allocationStages = finished.Stages
+			fake.failedAllocations = testCase.failedAllocations
+			fake.definitions[3] = kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"}
+			unitIds := []int64{}
+			for _, unit := range testCase.units {
+				fake.units[unit.Id] = unit
+				unitIds = append(unitIds, unit.Id)
+			}
+
+			r := &sandboxPoolResource{client: client}
+			plan := tfsdk.Plan(newTestState(t, r, map[string]any{
+				"id":           int64(7),
+				"max_size":     int64(3),
+				"desired_size": testCase.desiredSize,
+				"definition":   kypo.SandboxDefinition{Id: 3},
+				"poll_times":   testPollTimes,
+			}))
+
+			var state tfsdk.State
+			var diags diag.Diagnostics
+			if testCase.pool == nil {
+				resp := resource.CreateResponse{State: tfsdk.State{Schema: plan.Schema, Raw: plan.Raw.Copy()}}
+				r.Create(ctx, resource.CreateRequest{Plan: plan}, &resp)
+				state, diags = resp.State, resp.Diagnostics
+			} else {
+				fake.pools[7] = *testCase.pool
+				priorState := newTestState(t, r, map[string]any{
+					"id":                  int64(7),
+					"desired_size":        testCase.stateDesiredSize,
+					"allocation_unit_ids": unitIds,
+				})
+				resp := resource.UpdateResponse{State: priorState}
+				r.Update(ctx, resource.UpdateRequest{State: priorState, Plan: plan}, &resp)
+				state, diags = resp.State, resp.Diagnostics
+			}
+
+			if diags.HasError() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if diags.WarningsCount() != testCase.expectWarnings {
+				t.Errorf("expected %d warnings, got diagnostics: %v", testCase.expectWarnings, diags)
+			}
+
+			var healthySize types.Int64
+			var stateUnitIds []int64
+			state.GetAttribute(ctx, path.Root("healthy_size"), &healthySize)
+			state.GetAttribute(ctx, path.Root("allocation_unit_ids"), &stateUnitIds)
+			if !healthySize.Equal(types.Int64Value(testCase.expectHealthySize)) {
+				t.Errorf("expected healthy_size %d, got %s", testCase.expectHealthySize, healthySize)
+			}
+			if len(stateUnitIds) != testCase.expectUnitCount {
+				t.Errorf("expected %d allocation units, got %v", testCase.expectUnitCount, stateUnitIds)
+			}
+			for _, unitId := range testCase.expectKeptUnitIds {
+				if _, exists := fake.unit(unitId); !exists || !slices.Contains(stateUnitIds, unitId) {
+					t.Errorf("expected allocation unit %d to be kept, got %v", unitId, stateUnitIds)
+				}
+			}
+
+			var poolId types.Int64
+			state.GetAttribute(ctx, path.Root("id"), &poolId)
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if size := fake.pools[poolId.ValueInt64()].Size; size != testCase.expectPoolUnitSize {
+				t.Errorf("expected %d allocation units in the pool, got %d", testCase.expectPoolUnitSize, size)
+			}
+		})
+	}
+}
+
+func TestSandboxPoolResourceModifyPlan(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		planDesiredSize     any
+		healthySize         int64
+		expectUpdate        bool
+		expectUnitsKeptWarn bool
+	}{
+		"healthy": {
+			planDesiredSize: int64(2),
+			healthySize:     2,
+		},
+		"missing-healthy-units": {
+			planDesiredSize: int64(2),
+			healthySize:     1,
+			expectUpdate:    true,
+		},
+		"desired-size-removed": {
+			planDesiredSize:     types.Int64Null(),
+			healthySize:         2,
+			expectUnitsKeptWarn: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			r := &sandboxPoolResource{}
+			state := newTestState(t, r, map[string]any{
+				"id":                  int64(7),
+				"desired_size":        int64(2),
+				"healthy_size":        testCase.healthySize,
+				"allocation_unit_ids": []int64{1, 2},
+			})
+			plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw.Copy()}
+			plan.SetAttribute(ctx, path.Root("desired_size"), testCase.planDesiredSize)
+			resp := resource.ModifyPlanResponse{Plan: plan}
+
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{State: state, Plan: plan}, &resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+			}
+			if warned := resp.Diagnostics.WarningsCount() > 0; warned != testCase.expectUnitsKeptWarn {
+				t.Errorf("expected warning %t, got diagnostics: %v", testCase.expectUnitsKeptWarn, resp.Diagnostics)
+			}
+			var healthySize types.Int64
+			resp.Plan.GetAttribute(ctx, path.Root("healthy_size"), &healthySize)
+			if healthySize.IsUnknown() != testCase.expectUpdate {
+				t.Errorf("expected the update of the pool to be planned %t, got healthy_size %s", testCase.expectUpdate, healthySize)
+			}
+		})
+	}
+}
+
+func TestSandboxPoolResourceReadDeprecatedUsage(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stateSize  any
+		expectSize int64
+	}{
+		"kept":     {stateSize: int64(0), expectSize: 0},
+		"imported": {expectSize: 2},
+	}
+
+	for name, testCase := range testCases {
+		name, testCase := name, testCase
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			fake, client := newFakeKypo(t)
+			fake.pools[7] = kypo.SandboxPool{Id: 7, Size: 2, MaxSize: 2, HardwareUsage: kypo.HardwareUsage{Vcpu: "0.2"},
+				Definition: kypo.SandboxDefinition{Id: 3, Url: "https://gitlab.example/definition.git"}}
+
+			r := &sandboxPoolResource{client: client}
+			attributes := map[string]any{"id": int64(7), "definition": kypo.SandboxDefinition{Id: 3}}
+			if testCase.stateSize != nil {
+				attributes["size"] = testCase.stateSize
+				attributes["hardware_usage"] = kypo.HardwareUsage{Vcpu: "0.0"}
+			}
+			state := newTestState(t, r, attributes)
+			resp := resource.ReadResponse{State: state}
+			resp.Private = newTestPrivate(resp.Private)
+
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+			}
+			var size types.Int64
+			resp.State.GetAttribute(ctx, path.Root("size"), &size)
+			if !size.Equal(types.Int64Value(testCase.expectSize)) {
+				t.Errorf("expected size %d, got %s", testCase.expectSize, size)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 )
 
 func TestAccSandboxPoolResource(t *testing.T) {
@@ -23,17 +24,19 @@ resource "kypo_sandbox_pool" "test" {
 }
 `,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "size", "0"),
 					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "max_size", "2"),
 					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "rev", os.Getenv("TF_VAR_TAG_NAME")),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "id"),
-					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "rev_sha"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "allocation_unit_ids.#", "0"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "healthy_size", "0"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "size", "0"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.vcpu"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.ram"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.instances"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.network"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.subnet"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.port"),
+					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "rev_sha"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.id"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.sub"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.full_name"),
@@ -71,17 +74,19 @@ resource "kypo_sandbox_pool" "test" {
 }
 `,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "size", "0"),
 					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "max_size", "10"),
 					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "rev", os.Getenv("TF_VAR_TAG_NAME")),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "id"),
-					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "rev_sha"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "allocation_unit_ids.#", "0"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "healthy_size", "0"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "size", "0"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.vcpu"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.ram"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.instances"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.network"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.subnet"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "hardware_usage.port"),
+					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "rev_sha"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.id"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.sub"),
 					resource.TestCheckResourceAttrSet("kypo_sandbox_pool.test", "created_by.full_name"),
@@ -106,3 +111,62 @@ resource "kypo_sandbox_pool" "test" {
 		},
 	})
 }
+
+func TestAccSandboxPoolResourceForceDestroy(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders:        gitlabProvider,
+		// The removed block is needed to leave an allocation unit in the pool
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_7_0),
+		},
+		Steps: []resource.TestStep{
+			// Create a pool with an allocation unit
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size      = 1
+  force_destroy = true
+}
+
+resource "kypo_sandbox_allocation_unit" "test" {
+  pool_id = kypo_sandbox_pool.test.id
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "force_destroy", "true"),
+					resource.TestCheckResourceAttrPair("kypo_sandbox_allocation_unit.test", "pool_id",
+						"kypo_sandbox_pool.test", "id"),
+				),
+			},
+			// Stop managing the allocation unit without cleaning it up, so that the pool is not empty
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size      = 1
+  force_destroy = true
+}
+
+removed {
+  from = kypo_sandbox_allocation_unit.test
+
+  lifecycle {
+    destroy = false
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "force_destroy", "true"),
+					resource.TestCheckResourceAttr("kypo_sandbox_pool.test", "allocation_unit_ids.#", "0"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase, which fails unless force_destroy cleans up the allocation unit
+		},
+	})
+}
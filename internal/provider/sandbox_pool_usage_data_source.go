@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource              = &sandboxPoolUsageDataSource{}
+	_ datasource.DataSourceWithConfigure = &sandboxPoolUsageDataSource{}
+)
+
+// NewSandboxPoolUsageDataSource is a helper function to simplify the provider implementation.
+func NewSandboxPoolUsageDataSource() datasource.DataSource {
+	return &sandboxPoolUsageDataSource{}
+}
+
+// sandboxPoolUsageDataSource is the data source implementation.
+type sandboxPoolUsageDataSource struct {
+	client *kypo.Client
+}
+
+type sandboxPoolUsageModel struct {
+	Id            int64              `tfsdk:"id"`
+	Size          int64              `tfsdk:"size"`
+	MaxSize       int64              `tfsdk:"max_size"`
+	HardwareUsage hardwareUsageModel `tfsdk:"hardware_usage"`
+}
+
+type hardwareUsageModel struct {
+	Vcpu      types.Float64 `tfsdk:"vcpu"`
+	Ram       types.Float64 `tfsdk:"ram"`
+	Instances types.Float64 `tfsdk:"instances"`
+	Network   types.Float64 `tfsdk:"network"`
+	Subnet    types.Float64 `tfsdk:"subnet"`
+	Port      types.Float64 `tfsdk:"port"`
+}
+
+// parseHardwareUsage converts the hardware usage reported by KYPO to numbers. Values which are empty
+// or not a number, e.g. when the cloud quota cannot be read, are null.
+func parseHardwareUsage(hardwareUsage kypo.HardwareUsage) hardwareUsageModel {
+	return hardwareUsageModel{
+		Vcpu:      parseHardwareUsageValue(hardwareUsage.Vcpu),
+		Ram:       parseHardwareUsageValue(hardwareUsage.Ram),
+		Instances: parseHardwareUsageValue(hardwareUsage.Instances),
+		Network:   parseHardwareUsageValue(hardwareUsage.Network),
+		Subnet:    parseHardwareUsageValue(hardwareUsage.Subnet),
+		Port:      parseHardwareUsageValue(hardwareUsage.Port),
+	}
+}
+
+func parseHardwareUsageValue(value string) types.Float64 {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return types.Float64Null()
+	}
+	return types.Float64Value(number)
+}
+
+// Metadata returns the data source type name.
+func (r *sandboxPoolUsageDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_sandbox_pool_usage"
+}
+
+// Schema defines the schema for the data source.
+func (r *sandboxPoolUsageDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Current usage of a sandbox pool. These values change with every allocation and cleanup, which is why they are deprecated in the `kypo_sandbox_pool` resource.",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.Int64Attribute{
+				Required:            true,
+				MarkdownDescription: "Id of the sandbox pool",
+			},
+			"size": schema.Int64Attribute{
+				MarkdownDescription: "Current number of allocated sandbox allocation units",
+				Computed:            true,
+			},
+			"max_size": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of allocated sandbox allocation units",
+				Computed:            true,
+			},
+			"hardware_usage": schema.SingleNestedAttribute{
+				MarkdownDescription: "Current resource usage by all allocation units in the pool. Values which KYPO does not report as a number are null",
+				Computed:            true,
+				Attributes: map[string]schema.Attribute{
+					"vcpu": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used vCPUs relative to the cloud quota",
+						Computed:            true,
+					},
+					"ram": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used RAM relative to the cloud quota",
+						Computed:            true,
+					},
+					"instances": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used instances relative to the cloud quota",
+						Computed:            true,
+					},
+					"network": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used networks relative to the cloud quota",
+						Computed:            true,
+					},
+					"subnet": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used subnets relative to the cloud quota",
+						Computed:            true,
+					},
+					"port": schema.Float64Attribute{
+						MarkdownDescription: "The percentage of used ports relative to the cloud quota",
+						Computed:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *sandboxPoolUsageDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*kypo.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected kypo.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+	r.client = client
+}
+
+func (r *sandboxPoolUsageDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var id types.Int64
+
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("id"), &id)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, err := r.client.GetSandboxPool(ctx, id.ValueInt64())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read sandbox pool, got error: %s", err))
+		return
+	}
+
+	usage := sandboxPoolUsageModel{
+		Id:            pool.Id,
+		Size:          pool.Size,
+		MaxSize:       pool.MaxSize,
+		HardwareUsage: parseHardwareUsage(pool.HardwareUsage),
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &usage)...)
+}
@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+)
+
+func TestParseHardwareUsage(t *testing.T) {
+	t.Parallel()
+
+	hardwareUsage := parseHardwareUsage(kypo.HardwareUsage{
+		Vcpu: "12.5", Ram: "0", Instances: "", Network: "n/a", Subnet: "1e2", Port: "3",
+	})
+
+	expect := hardwareUsageModel{
+		Vcpu:      types.Float64Value(12.5),
+		Ram:       types.Float64Value(0),
+		Instances: types.Float64Null(),
+		Network:   types.Float64Null(),
+		Subnet:    types.Float64Value(100),
+		Port:      types.Float64Value(3),
+	}
+	if diff := cmp.Diff(hardwareUsage, expect); diff != "" {
+		t.Errorf("unexpected hardware usage difference: %s", diff)
+	}
+}
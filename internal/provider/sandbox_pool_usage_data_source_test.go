@@ -0,0 +1,43 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccSandboxPoolUsageDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders:        gitlabProvider,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: providerConfig + gitlabTestingDefinition + `
+resource "kypo_sandbox_pool" "test" {
+  definition = {
+    id = kypo_sandbox_definition.test.id
+  }
+  max_size = 2
+}
+
+data "kypo_sandbox_pool_usage" "test" {
+  id = kypo_sandbox_pool.test.id
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.kypo_sandbox_pool_usage.test", "id",
+						"kypo_sandbox_pool.test", "id"),
+					resource.TestCheckResourceAttr("data.kypo_sandbox_pool_usage.test", "size", "0"),
+					resource.TestCheckResourceAttr("data.kypo_sandbox_pool_usage.test", "max_size", "2"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.vcpu"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.ram"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.instances"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.network"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.subnet"),
+					resource.TestCheckResourceAttrSet("data.kypo_sandbox_pool_usage.test", "hardware_usage.port"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/vydrazde/kypo-go-client/pkg/kypo"
+	"golang.org/x/exp/slices"
+)
+
+// allocationRequestRunning reports whether the request has stages which are yet to finish. The stages after
+// a failed stage are never started and stay `IN_QUEUE`, so a failed request is not running.
+func allocationRequestRunning(request kypo.SandboxRequest) bool {
+	if allocationRequestFailed(request) {
+		return false
+	}
+	return slices.Contains(request.Stages, "RUNNING") || slices.Contains(request.Stages, "IN_QUEUE")
+}
+
+func allocationRequestFailed(request kypo.SandboxRequest) bool {
+	return slices.Contains(request.Stages, "FAILED")
+}
+
+// maxConcurrentRequests limits how many requests to KYPO are done at once when reading many allocation units.
+const maxConcurrentRequests = 10
+
+// getAllocationUnits reads all the given allocation units concurrently, with at most maxConcurrentRequests
+// requests at once. Allocation units which do not exist are returned as nil.
+func getAllocationUnits(ctx context.Context, client *kypo.Client, unitIds []int64) ([]*kypo.SandboxAllocationUnit, error) {
+	allocationUnits := make([]*kypo.SandboxAllocationUnit, len(unitIds))
+	errs := make([]error, len(unitIds))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrentRequests)
+	for i, unitId := range unitIds {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, unitId int64) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			allocationUnits[i], errs[i] = client.GetSandboxAllocationUnit(ctx, unitId)
+			if errors.Is(errs[i], kypo.ErrNotFound) {
+				allocationUnits[i], errs[i] = nil, nil
+			}
+		}(i, unitId)
+	}
+	wg.Wait()
+
+	return allocationUnits, errors.Join(errs...)
+}
+
+// awaitAllocationRequests waits until the allocation requests of all given allocation units finish.
+// All allocation units are checked together once every `pollTime` elapses. The given allocation units
+// are updated with the read allocation requests.
+func awaitAllocationRequests(ctx context.Context, client *kypo.Client, allocationUnits []kypo.SandboxAllocationUnit, pollTime time.Duration) error {
+	pending := make([]int, 0, len(allocationUnits))
+	for i := range allocationUnits {
+		pending = append(pending, i)
+	}
+
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		unitIds := make([]int64, 0, len(pending))
+		for _, i := range pending {
+			unitIds = append(unitIds, allocationUnits[i].Id)
+		}
+		read, err := getAllocationUnits(ctx, client, unitIds)
+		if err != nil {
+			return err
+		}
+
+		var stillPending []int
+		for j, i := range pending {
+			if read[j] == nil {
+				return &kypo.Error{ResourceName: "sandbox allocation unit", Identifier: allocationUnits[i].Id, Err: kypo.ErrNotFound}
+			}
+			allocationUnits[i] = *read[j]
+			request := allocationUnits[i].AllocationRequest
+			if request.Id == 0 || len(request.Stages) == 0 || allocationRequestRunning(request) {
+				stillPending = append(stillPending, i)
+			}
+		}
+		pending = stillPending
+
+		tflog.Info(ctx, fmt.Sprintf("%d of %d sandbox allocation units finished", len(allocationUnits)-len(pending), len(allocationUnits)))
+	}
+	return nil
+}
+
+// awaitCleanupRequests waits until the cleanup requests of all given allocation units finish, which is when
+// the allocation units no longer exist. All allocation units are checked together once every `pollTime` elapses.
+func awaitCleanupRequests(ctx context.Context, client *kypo.Client, unitIds []int64, pollTime time.Duration) error {
+	pending := unitIds
+
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		read, err := getAllocationUnits(ctx, client, pending)
+		if err != nil {
+			return err
+		}
+
+		var stillPending []int64
+		for i, unitId := range pending {
+			// After cleanup is finished the allocation unit is deleted and 404 is thrown
+			if read[i] == nil {
+				continue
+			}
+			if allocationRequestFailed(read[i].CleanupRequest) {
+				return &kypo.Error{ResourceName: "sandbox cleanup request", Identifier: fmt.Sprintf("sandbox allocation unit %d", unitId),
+					Err: fmt.Errorf("sandbox cleanup request finished with error")}
+			}
+			stillPending = append(stillPending, unitId)
+		}
+		pending = stillPending
+
+		tflog.Info(ctx, fmt.Sprintf("%d of %d sandbox allocation units cleaned up", len(unitIds)-len(pending), len(unitIds)))
+	}
+	return nil
+}
+
+// cleanupAllocationUnits cancels running allocation requests of the given allocation units, starts their cleanup
+// and waits until all cleanup requests finish.
+func cleanupAllocationUnits(ctx context.Context, client *kypo.Client, allocationUnits []kypo.SandboxAllocationUnit, pollTime time.Duration) error {
+	var started []int64
+	for _, allocationUnit := range allocationUnits {
+		if allocationRequestRunning(allocationUnit.AllocationRequest) {
+			err := client.CancelSandboxAllocationRequest(ctx, allocationUnit.AllocationRequest.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := client.CreateSandboxCleanupRequest(ctx, allocationUnit.Id)
+		if errors.Is(err, kypo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		started = append(started, allocationUnit.Id)
+	}
+
+	return awaitCleanupRequests(ctx, client, started, pollTime)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+var _ validator.String = gitUrlValidator{}
+
+// scpLikeGitUrl matches SSH Git URLs in the scp-like syntax, such as `git@host:group/repo.git`.
+var scpLikeGitUrl = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^\\\s]+$`)
+
+// scpLikeGitUrlWithoutUser matches SSH Git URLs in the scp-like syntax without a user, such as `host:group/repo.git`.
+var scpLikeGitUrlWithoutUser = regexp.MustCompile(`^[\w.-]+:[^/\\\s][^\\\s]*$`)
+
+// windowsPath matches absolute paths on Windows, such as `C:\repos\definition`.
+var windowsPath = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+
+// gitUrlValidator validates that a string Attribute's value is a remote Git repository URL.
+type gitUrlValidator struct {
+}
+
+// Description describes the validation in plain text formatting.
+func (validator gitUrlValidator) Description(_ context.Context) string {
+	return `must be an SSH URL such as "git@gitlab.com:group/repo.git" or an HTTPS URL such as "https://gitlab.com/group/repo.git" of a remote Git repository.`
+}
+
+// MarkdownDescription describes the validation in Markdown formatting.
+func (validator gitUrlValidator) MarkdownDescription(ctx context.Context) string {
+	return validator.Description(ctx)
+}
+
+// ValidateString performs the validation.
+func (validator gitUrlValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	s := req.ConfigValue
+
+	if s.IsUnknown() || s.IsNull() {
+		return
+	}
+
+	value := s.ValueString()
+
+	if isLocalPath(value) {
+		resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+			req.Path,
+			"Invalid Attribute Value Git URL",
+			fmt.Sprintf("%q is a local path, the repository must be reachable by the KYPO instance, the value %s", value, validator.Description(ctx))),
+		)
+		return
+	}
+
+	repositoryPath, ok := remoteRepositoryPath(value)
+	if !ok {
+		resp.Diagnostics.Append(diag.NewAttributeErrorDiagnostic(
+			req.Path,
+			"Invalid Attribute Value Git URL",
+			fmt.Sprintf("%q %s", value, validator.Description(ctx))),
+		)
+		return
+	}
+
+	if scpLikeGitUrlWithoutUser.MatchString(value) {
+		resp.Diagnostics.Append(diag.NewAttributeWarningDiagnostic(
+			req.Path,
+			"Git URL Without User",
+			fmt.Sprintf("%q does not specify a user, Git servers usually expect one such as \"git@host:group/repo.git\"", value)),
+		)
+	}
+
+	if strings.HasPrefix(strings.ToLower(value), "http://") {
+		resp.Diagnostics.Append(diag.NewAttributeWarningDiagnostic(
+			req.Path,
+			"Insecure Git URL",
+			fmt.Sprintf("%q uses plain HTTP, the repository is cloned unencrypted, an HTTPS or SSH URL is recommended", value)),
+		)
+	}
+
+	if !strings.HasSuffix(repositoryPath, ".git") {
+		resp.Diagnostics.Append(diag.NewAttributeWarningDiagnostic(
+			req.Path,
+			"Git URL Without .git Suffix",
+			fmt.Sprintf("%q does not end with \".git\", some Git servers will not be able to resolve the repository", value)),
+		)
+	}
+}
+
+// isLocalPath reports whether the value refers to a repository on the local filesystem.
+func isLocalPath(value string) bool {
+	return strings.HasPrefix(value, "/") ||
+		strings.HasPrefix(value, "./") ||
+		strings.HasPrefix(value, "../") ||
+		strings.HasPrefix(value, "~") ||
+		strings.HasPrefix(value, "file:") ||
+		windowsPath.MatchString(value)
+}
+
+// remoteRepositoryPath returns the path to the repository on the Git server
+// and whether the value is a supported SSH, HTTPS or HTTP Git URL.
+func remoteRepositoryPath(value string) (string, bool) {
+	if scpLikeGitUrl.MatchString(value) || scpLikeGitUrlWithoutUser.MatchString(value) {
+		return value[strings.Index(value, ":")+1:], true
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "ssh" && parsed.Scheme != "http" {
+		return "", false
+	}
+
+	repositoryPath := strings.TrimSuffix(parsed.Path, "/")
+	if strings.Trim(repositoryPath, "/") == "" {
+		return "", false
+	}
+	return repositoryPath, true
+}
+
+// GitUrl returns an AttributeValidator which ensures that any configured
+// attribute value:
+//
+//   - Is an SSH (`git@host:group/repo.git` or `ssh://git@host/group/repo.git`)
+//     or HTTPS (`https://host/group/repo.git`) URL of a remote Git repository.
+//   - Is not a local path.
+//
+// Plain HTTP URLs and scp-like SSH URLs without a user (`host:group/repo.git`)
+// are accepted with a warning, as they were before the validation was added.
+// A warning is also emitted when the URL does not end with the `.git` suffix.
+// Null (unconfigured) and unknown (known after apply) values are skipped.
+func GitUrl() validator.String {
+	return gitUrlValidator{}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validators_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"terraform-provider-kypo/internal/validators"
+)
+
+func TestGitUrl(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		val                 types.String
+		expectedDiagnostics diag.Diagnostics
+	}
+
+	const description = `must be an SSH URL such as "git@gitlab.com:group/repo.git" or an HTTPS URL such as "https://gitlab.com/group/repo.git" of a remote Git repository.`
+
+	tests := map[string]testCase{
+		"unknown": {
+			val: types.StringUnknown(),
+		},
+		"null": {
+			val: types.StringNull(),
+		},
+		"valid-scp-like": {
+			val: types.StringValue("git@gitlab.ics.muni.cz:muni-kypo-trainings/games/junior-hacker.git"),
+		},
+		"valid-ssh": {
+			val: types.StringValue("ssh://git@gitlab.ics.muni.cz:2222/muni-kypo-trainings/games/junior-hacker.git"),
+		},
+		"valid-https": {
+			val: types.StringValue("https://gitlab.ics.muni.cz/muni-kypo-trainings/games/junior-hacker.git"),
+		},
+		"missing-suffix": {
+			val: types.StringValue("https://gitlab.ics.muni.cz/muni-kypo-trainings/games/junior-hacker"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(
+					path.Root("test"),
+					"Git URL Without .git Suffix",
+					`"https://gitlab.ics.muni.cz/muni-kypo-trainings/games/junior-hacker" does not end with ".git", some Git servers will not be able to resolve the repository`,
+				),
+			},
+		},
+		"local-path": {
+			val: types.StringValue("/home/user/junior-hacker.git"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeErrorDiagnostic(
+					path.Root("test"),
+					"Invalid Attribute Value Git URL",
+					`"/home/user/junior-hacker.git" is a local path, the repository must be reachable by the KYPO instance, the value `+description,
+				),
+			},
+		},
+		"file-url": {
+			val: types.StringValue("file:///home/user/junior-hacker.git"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeErrorDiagnostic(
+					path.Root("test"),
+					"Invalid Attribute Value Git URL",
+					`"file:///home/user/junior-hacker.git" is a local path, the repository must be reachable by the KYPO instance, the value `+description,
+				),
+			},
+		},
+		"http": {
+			val: types.StringValue("http://gitlab.ics.muni.cz/muni-kypo-trainings/games/junior-hacker.git"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(
+					path.Root("test"),
+					"Insecure Git URL",
+					`"http://gitlab.ics.muni.cz/muni-kypo-trainings/games/junior-hacker.git" uses plain HTTP, the repository is cloned unencrypted, an HTTPS or SSH URL is recommended`,
+				),
+			},
+		},
+		"scp-like-without-user": {
+			val: types.StringValue("gitlab.ics.muni.cz:muni-kypo-trainings/games/junior-hacker.git"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeWarningDiagnostic(
+					path.Root("test"),
+					"Git URL Without User",
+					`"gitlab.ics.muni.cz:muni-kypo-trainings/games/junior-hacker.git" does not specify a user, Git servers usually expect one such as "git@host:group/repo.git"`,
+				),
+			},
+		},
+		"missing-path": {
+			val: types.StringValue("https://gitlab.ics.muni.cz/"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeErrorDiagnostic(
+					path.Root("test"),
+					"Invalid Attribute Value Git URL",
+					`"https://gitlab.ics.muni.cz/" `+description,
+				),
+			},
+		},
+		"invalid": {
+			val: types.StringValue("junior-hacker"),
+			expectedDiagnostics: diag.Diagnostics{
+				diag.NewAttributeErrorDiagnostic(
+					path.Root("test"),
+					"Invalid Attribute Value Git URL",
+					`"junior-hacker" `+description,
+				),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			request := validator.StringRequest{
+				Path:           path.Root("test"),
+				PathExpression: path.MatchRoot("test"),
+				ConfigValue:    test.val,
+			}
+
+			response := validator.StringResponse{}
+
+			validators.GitUrl().ValidateString(context.Background(), request, &response)
+
+			if diff := cmp.Diff(response.Diagnostics, test.expectedDiagnostics); diff != "" {
+				t.Errorf("unexpected diagnostics difference: %s", diff)
+			}
+		})
+	}
+}